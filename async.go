@@ -0,0 +1,222 @@
+package golog
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy defines what happens when the async queue is full.
+type OverflowPolicy int
+
+// Overflow policies of the async queue:
+// OverflowBlock - the logging call waits for free space (default);
+// OverflowDropNewest - the new entry is dropped;
+// OverflowDropOldest - the oldest queued entry is dropped;
+// OverflowDropBelow - the new entry is dropped if its level is
+// below AsyncConfig.DropBelow, otherwise the logging call waits.
+const (
+	OverflowBlock OverflowPolicy = iota
+	OverflowDropNewest
+	OverflowDropOldest
+	OverflowDropBelow
+)
+
+// asyncSizeDefault is the queue size used if AsyncConfig.Size is not set.
+const asyncSizeDefault = 1024
+
+// AsyncConfig defines the async mode of the logger.
+type AsyncConfig struct {
+	// Size is the max number of queued entries (1024 if not set).
+	Size int
+	// Overflow is the policy used when the queue is full.
+	Overflow OverflowPolicy
+	// DropBelow is the level used by OverflowDropBelow.
+	DropBelow Level
+	// SyncErrors makes error entries and above bypass the queue:
+	// they are written synchronously, so they may precede
+	// entries queued earlier.
+	SyncErrors bool
+}
+
+type asyncItem struct {
+	c *config
+	e *Entry
+}
+
+// asyncQueue is a bounded queue of entries written by a background goroutine.
+type asyncQueue struct {
+	cfg     AsyncConfig
+	write   func(c *config, e *Entry)
+	dropped *uint64 // atomic counter of the logger's core
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond // the queue is empty and the worker doesn't write
+	items    []asyncItem
+	head, n  int
+	busy     bool
+	closed   bool
+	done     chan struct{}
+}
+
+func newAsyncQueue(cfg AsyncConfig, write func(c *config, e *Entry), dropped *uint64) *asyncQueue {
+	if cfg.Size <= 0 {
+		cfg.Size = asyncSizeDefault
+	}
+	q := &asyncQueue{
+		cfg:     cfg,
+		write:   write,
+		dropped: dropped,
+		items:   make([]asyncItem, cfg.Size),
+		done:    make(chan struct{}),
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	q.idle = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+// push queues e according to the overflow policy.
+// If the queue is closed, e is written synchronously.
+func (q *asyncQueue) push(c *config, e *Entry) {
+	q.mu.Lock()
+	if q.n == len(q.items) && !q.closed {
+		switch q.cfg.Overflow {
+		case OverflowDropNewest:
+			q.mu.Unlock()
+			atomic.AddUint64(q.dropped, 1)
+			return
+		case OverflowDropOldest:
+			q.pop()
+			atomic.AddUint64(q.dropped, 1)
+		case OverflowDropBelow:
+			if e.Level < q.cfg.DropBelow {
+				q.mu.Unlock()
+				atomic.AddUint64(q.dropped, 1)
+				return
+			}
+		}
+		for q.n == len(q.items) && !q.closed {
+			q.notFull.Wait()
+		}
+	}
+	if q.closed {
+		q.mu.Unlock()
+		q.write(c, e)
+		return
+	}
+	q.items[(q.head+q.n)%len(q.items)] = asyncItem{c: c, e: e}
+	q.n++
+	q.notEmpty.Signal()
+	q.mu.Unlock()
+}
+
+// pop removes the oldest item, q.mu must be held.
+func (q *asyncQueue) pop() asyncItem {
+	it := q.items[q.head]
+	q.items[q.head] = asyncItem{}
+	q.head = (q.head + 1) % len(q.items)
+	q.n--
+	return it
+}
+
+// run writes queued entries until the queue is closed and drained.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		for q.n == 0 && !q.closed {
+			q.idle.Broadcast()
+			q.notEmpty.Wait()
+		}
+		if q.n == 0 {
+			q.idle.Broadcast()
+			return
+		}
+		it := q.pop()
+		q.busy = true
+		q.notFull.Signal()
+		q.mu.Unlock()
+		q.write(it.c, it.e)
+		q.mu.Lock()
+		q.busy = false
+	}
+}
+
+// flush waits until all queued entries are written.
+func (q *asyncQueue) flush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.n > 0 || q.busy {
+		q.idle.Wait()
+	}
+}
+
+// close drains the queue and stops the worker,
+// entries pushed after close are written synchronously.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mu.Unlock()
+	<-q.done
+}
+
+// SetAsync switches the logger to the async mode: entries are queued
+// and written by a background goroutine, so slow writers don't stall
+// logging calls. Panic and fatal entries are always written synchronously
+// after the queue is flushed.
+// Calling SetAsync again replaces the queue (the old one is drained).
+// Use Flush() to wait for queued entries and Close() to return
+// to the synchronous mode.
+func (l *Logger) SetAsync(cfg AsyncConfig) {
+	q := newAsyncQueue(cfg, l.writeEntry, &l.core.dropped)
+	var old *asyncQueue
+	l.update(func(c *config) {
+		old = c.async
+		c.async = q
+	})
+	if old != nil {
+		old.close()
+	}
+}
+
+// Flush writes summaries of pending duplicate entries (see SetDedup())
+// and waits until all entries queued in the async mode are written.
+func (l *Logger) Flush() {
+	c := l.config()
+	if c.dedup != nil {
+		c.dedup.flush()
+	}
+	if c.async != nil {
+		c.async.flush()
+	}
+}
+
+// Close writes summaries of pending duplicate entries and all entries
+// queued in the async mode, stops the background goroutine and
+// returns the logger to the synchronous mode.
+// Outputs of the logger aren't closed.
+func (l *Logger) Close() {
+	if d := l.config().dedup; d != nil {
+		d.flush()
+	}
+	var old *asyncQueue
+	l.update(func(c *config) {
+		old = c.async
+		c.async = nil
+	})
+	if old != nil {
+		old.close()
+	}
+}
+
+// Dropped returns the number of entries dropped by the async queue
+// because of its overflow policy.
+func (l *Logger) Dropped() uint64 {
+	return atomic.LoadUint64(&l.core.dropped)
+}
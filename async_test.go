@@ -0,0 +1,102 @@
+package golog
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gateWriter blocks writes until the gate is opened.
+type gateWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{started: make(chan struct{}, 100), gate: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	for _, c := range []struct {
+		policy  OverflowPolicy
+		want    string
+		dropped uint64
+	}{
+		{OverflowDropNewest, "1 2 3", 2},
+		{OverflowDropOldest, "1 4 5", 2},
+		{OverflowDropBelow, "1 2 3 5", 1},
+	} {
+		w := newGateWriter()
+		l := New("", 0)
+		l.SetOutput(w, w)
+		l.SetAsync(AsyncConfig{Size: 2, Overflow: c.policy, DropBelow: LevelError})
+		l.Infoln(1)
+		<-w.started // the worker is writing 1, the queue is empty
+		l.Infoln(2)
+		l.Infoln(3)
+		l.Infoln(4)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if c.policy == OverflowDropBelow {
+				// waits for free space
+				l.Errorln(5)
+			} else {
+				l.Infoln(5)
+			}
+		}()
+		if c.policy != OverflowDropBelow {
+			<-done
+		}
+		close(w.gate)
+		<-done
+		l.Close()
+
+		got := strings.Fields(strings.NewReplacer("[INF]", "", "[ERR]", "").Replace(w.String()))
+		if strings.Join(got, " ") != c.want {
+			t.Errorf("policy %d: got %v, want %s", c.policy, got, c.want)
+		}
+		if l.Dropped() != c.dropped {
+			t.Errorf("policy %d: dropped %d, want %d", c.policy, l.Dropped(), c.dropped)
+		}
+	}
+}
+
+func TestAsyncFlush(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetAsync(AsyncConfig{Size: 8})
+	var want strings.Builder
+	for i := 0; i < 100; i++ {
+		l.Infoln(i)
+		want.WriteString("[INF] " + strconv.Itoa(i) + "\n")
+	}
+	l.Flush()
+	if out.String() != want.String() {
+		t.Errorf("got %q", out.String())
+	}
+	l.Close()
+	l.Infoln("sync")
+	if !strings.HasSuffix(out.String(), "[INF] sync\n") {
+		t.Errorf("not written after Close: %q", out.String())
+	}
+}
@@ -0,0 +1,42 @@
+package golog
+
+import "time"
+
+// Delays of reconnections of SyslogHook, GELFHook and JournaldHook.
+const (
+	reconnectMinBackoff = 100 * time.Millisecond
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// backoff delays retries of network sinks after failures exponentially,
+// so an unavailable server doesn't stall the logger with dialing
+// and flood ErrDefault with errors of every entry.
+type backoff struct {
+	min, max time.Duration
+	delay    time.Duration
+	retryAt  time.Time
+}
+
+// wait reports whether the next attempt must be delayed.
+func (b *backoff) wait() bool {
+	return time.Now().Before(b.retryAt)
+}
+
+// failed doubles the delay from min up to max,
+// it reports whether it's the first failure in a row.
+func (b *backoff) failed() bool {
+	first := b.delay == 0
+	if first {
+		b.delay = b.min
+	} else if b.delay *= 2; b.delay > b.max {
+		b.delay = b.max
+	}
+	b.retryAt = time.Now().Add(b.delay)
+	return first
+}
+
+// reset resets the delay after a successful attempt.
+func (b *backoff) reset() {
+	b.delay = 0
+	b.retryAt = time.Time{}
+}
@@ -0,0 +1,179 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// batchItem is a buffered entry of a batching hook,
+// size is counted against the max size of a batch.
+type batchItem struct {
+	value interface{}
+	size  int
+}
+
+// batcher buffers entries of FluentHook and LokiHook and sends them
+// in batches by a background goroutine when batchSize is reached
+// or interval elapsed. Failed batches are resent with exponential
+// backoff, the oldest entries are dropped if the buffer is full.
+type batcher struct {
+	name      string        // of the sink in errors
+	bufSize   int           // max number of buffered entries
+	batchSize int           // max size of a batch
+	interval  time.Duration // max delay of buffered entries
+	timeout   time.Duration // max wait of flush
+	// send sends a batch and returns the number of sent entries,
+	// unsent entries are resent.
+	send func(batch []batchItem) (int, error)
+	// stop is called by the goroutine before it exits, it can be nil.
+	stop    func()
+	dropped uint64 // atomic
+
+	mu     sync.Mutex
+	items  []batchItem
+	size   int // size of items
+	closed bool
+
+	wake     chan struct{}
+	flushReq chan chan error
+	closing  chan struct{}
+	done     chan struct{}
+
+	// used by the background goroutine only
+	pending []batchItem // entries being sent
+	retry   backoff
+}
+
+// start starts the background goroutine of b with the backoff
+// of retries from minBackoff up to maxBackoff.
+func (b *batcher) start(minBackoff, maxBackoff time.Duration) {
+	b.retry = backoff{min: minBackoff, max: maxBackoff}
+	b.wake = make(chan struct{}, 1)
+	b.flushReq = make(chan chan error)
+	b.closing = make(chan struct{})
+	b.done = make(chan struct{})
+	go b.run()
+}
+
+// add buffers v.
+func (b *batcher) add(v interface{}, size int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return os.ErrClosed
+	}
+	if len(b.items) >= b.bufSize {
+		b.size -= b.items[0].size
+		b.items[0] = batchItem{}
+		b.items = b.items[1:]
+		atomic.AddUint64(&b.dropped, 1)
+	}
+	b.items = append(b.items, batchItem{value: v, size: size})
+	b.size += size
+	if b.size >= b.batchSize {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// flush sends buffered entries without waiting for backoff of retries,
+// it waits no longer than the timeout.
+func (b *batcher) flush() error {
+	ch := make(chan error, 1)
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+	select {
+	case b.flushReq <- ch:
+	case <-b.done:
+		return os.ErrClosed
+	case <-timer.C:
+		return fmt.Errorf("golog: %s flush timed out", b.name)
+	}
+	select {
+	case err := <-ch:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("golog: %s flush timed out", b.name)
+	}
+}
+
+// close sends buffered entries (one attempt) and stops the goroutine.
+func (b *batcher) close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return os.ErrClosed
+	}
+	b.closed = true
+	b.mu.Unlock()
+	close(b.closing)
+	<-b.done
+	return nil
+}
+
+// run sends buffered entries periodically and on requests.
+func (b *batcher) run() {
+	defer close(b.done)
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.closing:
+			if err := b.sendAll(); err != nil {
+				fmt.Fprintf(ErrDefault, "golog: failed to send entries to %s: %v\n", b.name, err)
+			}
+			if b.stop != nil {
+				b.stop()
+			}
+			return
+		case ch := <-b.flushReq:
+			ch <- b.sendAll()
+			continue
+		case <-ticker.C:
+		case <-b.wake:
+		}
+		if b.retry.wait() {
+			continue
+		}
+		if err := b.sendAll(); err != nil {
+			if b.retry.failed() {
+				fmt.Fprintf(ErrDefault, "golog: failed to send entries to %s: %v\n", b.name, err)
+			}
+			continue
+		}
+		b.retry.reset()
+	}
+}
+
+// sendAll sends pending and buffered entries until the buffer is empty.
+func (b *batcher) sendAll() error {
+	for {
+		if len(b.pending) == 0 {
+			b.mu.Lock()
+			n, size := 0, 0
+			for n < len(b.items) && (n == 0 || size+b.items[n].size <= b.batchSize) {
+				size += b.items[n].size
+				n++
+			}
+			b.pending = append(b.pending[:0], b.items[:n]...)
+			// copy to let the buffer's array be collected
+			b.items = append([]batchItem(nil), b.items[n:]...)
+			b.size -= size
+			b.mu.Unlock()
+		}
+		if len(b.pending) == 0 {
+			return nil
+		}
+		n, err := b.send(b.pending)
+		b.pending = append(b.pending[:0], b.pending[n:]...)
+		if err != nil {
+			return err
+		}
+	}
+}
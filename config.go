@@ -0,0 +1,88 @@
+package golog
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// config is an immutable snapshot of the logger's configuration.
+// Setters never modify it in place: they store an updated copy
+// (copy-on-write), so logging calls always see a consistent
+// configuration without locking.
+type config struct {
+	flags        int
+	customPrefix string
+	level        Level
+	format       Format
+	outWriter    io.Writer
+	errWriter    io.Writer
+	errThreshold Level               // lowest level written to errWriter
+	stackLevel   Level               // lowest level with stack traces
+	levelWriters [LevelOff]io.Writer // per-level writers override out/err split
+	extractors   []ContextExtractor
+	slogHandler  slog.Handler // replaces writers if set
+	hooks        [LevelOff][]Hook
+	hasHooks     bool
+	async        *asyncQueue // nil in the synchronous mode
+	sampler      *sampler    // nil if sampling is disabled
+	dedup        *dedup      // nil if deduplication is disabled
+	exitCode     int
+	exitFunc     func(code int)
+}
+
+// writer returns the output destination for messages of the level.
+func (c *config) writer(lvl Level) io.Writer {
+	if lvl >= LevelTrace && lvl < LevelOff && c.levelWriters[lvl] != nil {
+		return c.levelWriters[lvl]
+	}
+	if lvl < c.errThreshold {
+		return c.outWriter
+	}
+	return c.errWriter
+}
+
+// needCaller reports whether entries must contain the caller.
+func (c *config) needCaller() bool {
+	return c.flags&(log.Lshortfile|log.Llongfile) != 0 || c.slogHandler != nil || c.hasHooks
+}
+
+// core is the state shared by a logger and its child loggers.
+type core struct {
+	// atomic counters are first for alignment
+	dropped    uint64       // entries dropped by async queues
+	sampledOut uint64       // entries suppressed by samplers
+	mu         sync.Mutex   // serializes writes
+	updMu      sync.Mutex   // serializes configuration updates
+	cfg        atomic.Value // *config
+}
+
+// config returns the current configuration snapshot.
+func (l *Logger) config() *config {
+	return l.core.cfg.Load().(*config)
+}
+
+// update applies fn to a copy of the current configuration
+// and stores the copy atomically.
+func (l *Logger) update(fn func(c *config)) {
+	l.core.updMu.Lock()
+	defer l.core.updMu.Unlock()
+	c := *l.config()
+	fn(&c)
+	l.core.cfg.Store(&c)
+}
+
+// Snapshot saves the logger's configuration, restore() brings back
+// everything changed by setters since the call (level, prefix, flags,
+// outputs, format, hooks etc.), e.g. `defer l.Snapshot()()` in tests.
+// Async queues enabled after the snapshot should be closed explicitly.
+func (l *Logger) Snapshot() (restore func()) {
+	saved := l.config()
+	return func() {
+		l.update(func(c *config) {
+			*c = *saved
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package golog
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContextExtractor returns key/value pairs (key1, value1, key2, value2, ...)
+// extracted from ctx (request ID, trace ID etc.)
+// which are rendered as fields of entries logged with *Context() methods.
+type ContextExtractor func(ctx context.Context) []interface{}
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying the logger l.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx (see NewContext())
+// or the global logger if there is no one.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return loggerGlobal.With()
+}
+
+// AddContextExtractor adds the extractor which is called for every entry
+// logged with *Context() methods. Extractors are called in order of addition.
+func (l *Logger) AddContextExtractor(fn ContextExtractor) {
+	l.update(func(c *config) {
+		// copy to keep previous snapshots immutable
+		c.extractors = append(c.extractors[:len(c.extractors):len(c.extractors)], fn)
+	})
+}
+
+func (l *Logger) outputctx(ctx context.Context, lvl Level, v ...interface{}) {
+	c := l.config()
+	if lvl < c.level {
+		return
+	}
+	var keyvals []interface{}
+	if ctx != nil {
+		for _, fn := range c.extractors {
+			keyvals = append(keyvals, fn(ctx)...)
+		}
+	}
+	l.emit(c, lvl, fmt.Sprint(v...), keyvals)
+}
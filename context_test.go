@@ -0,0 +1,34 @@
+package golog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type requestIDKey struct{}
+
+func TestContext(t *testing.T) {
+	var out bytes.Buffer
+	l := New("ctx:", 0)
+	l.SetOutput(&out, &out)
+	l.AddContextExtractor(func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"request_id", id}
+		}
+		return nil
+	})
+
+	ctx := NewContext(context.WithValue(context.Background(), requestIDKey{}, "abc"), l.With("user", 1))
+	FromContext(ctx).InfoContext(ctx, "Started ", 42)
+	FromContext(ctx).DebugContext(context.Background(), "No request")
+
+	want := "[INF] ctx: Started 42 user=1 request_id=abc\n" +
+		"[DBG] ctx: No request user=1\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+	if FromContext(context.Background()).core != loggerGlobal.core {
+		t.Error("FromContext without logger must return the global logger")
+	}
+}
@@ -0,0 +1,125 @@
+package golog
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type dedupKey struct {
+	level  Level
+	prefix string
+}
+
+// dedupRun is a run of identical entries.
+type dedupRun struct {
+	c *config
+	// message and fields of the first entry are copied
+	// because hooks can modify the entry after it's written
+	msg      string
+	fields   []Field
+	last     *Entry // the last suppressed entry
+	repeated int
+	timer    *time.Timer
+}
+
+// dedup collapses identical entries of the same level and prefix
+// which come within the window after the first one.
+type dedup struct {
+	window time.Duration
+	write  func(c *config, e *Entry)
+
+	mu   sync.Mutex
+	runs map[dedupKey]*dedupRun
+}
+
+func newDedup(window time.Duration, write func(c *config, e *Entry)) *dedup {
+	return &dedup{window: window, write: write, runs: make(map[dedupKey]*dedupRun)}
+}
+
+// allow reports whether e must be written:
+// repeats of the current run are suppressed,
+// a different entry ends the run and writes its summary.
+func (d *dedup) allow(c *config, e *Entry) bool {
+	key := dedupKey{level: e.Level, prefix: e.Prefix}
+	d.mu.Lock()
+	r := d.runs[key]
+	if r != nil && r.msg == e.Message && reflect.DeepEqual(r.fields, e.Fields) {
+		r.repeated++
+		r.last = e
+		d.mu.Unlock()
+		return false
+	}
+	run := &dedupRun{c: c, msg: e.Message, fields: append([]Field(nil), e.Fields...)}
+	d.runs[key] = run
+	run.timer = time.AfterFunc(d.window, func() { d.expire(key, run) })
+	d.mu.Unlock()
+
+	if r != nil {
+		r.timer.Stop()
+		d.summarize(r)
+	}
+	return true
+}
+
+// expire closes the window of the run.
+func (d *dedup) expire(key dedupKey, r *dedupRun) {
+	d.mu.Lock()
+	if d.runs[key] != r {
+		// the run was ended by a different entry
+		d.mu.Unlock()
+		return
+	}
+	delete(d.runs, key)
+	d.mu.Unlock()
+	d.summarize(r)
+}
+
+// flush ends all runs and writes their summaries.
+func (d *dedup) flush() {
+	d.mu.Lock()
+	runs := d.runs
+	d.runs = make(map[dedupKey]*dedupRun)
+	d.mu.Unlock()
+	for _, r := range runs {
+		r.timer.Stop()
+		d.summarize(r)
+	}
+}
+
+// summarize writes the summary entry of the run if it has repeats:
+// "message repeated 42 times: <message>".
+func (d *dedup) summarize(r *dedupRun) {
+	if r.repeated == 0 {
+		return
+	}
+	e := *r.last
+	e.Time = time.Now()
+	times := "times"
+	if r.repeated == 1 {
+		times = "time"
+	}
+	e.Message = fmt.Sprintf("message repeated %d %s: %s", r.repeated, times, r.msg)
+	d.write(r.c, &e)
+}
+
+// SetDedup enables suppression of identical entries (same level,
+// prefix, message and fields) which come within the window after
+// the first one: a single summary entry
+// "message repeated N times: <message>" is written when a different
+// entry comes or the window closes. window==0 disables suppression.
+func (l *Logger) SetDedup(window time.Duration) {
+	var d *dedup
+	if window > 0 {
+		d = newDedup(window, l.dispatch)
+	}
+	var old *dedup
+	l.update(func(c *config) {
+		old = c.dedup
+		c.dedup = d
+	})
+	if old != nil {
+		old.flush()
+	}
+}
@@ -0,0 +1,68 @@
+package golog
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDedup(t *testing.T) {
+	var out bytes.Buffer
+	l := New("dedup:", 0)
+	l.SetOutput(&out, &out)
+	l.SetDedup(time.Hour)
+
+	for i := 0; i < 42; i++ {
+		l.Errorln("connection refused")
+	}
+	l.Infoln("connection refused") // another level
+	l.Errorln("retrying")
+	l.Errorw("retrying", "attempt", 2)
+	l.Errorw("retrying", "attempt", 2)
+	l.Flush()
+
+	want := "[ERR] dedup: connection refused\n" +
+		"[INF] dedup: connection refused\n" +
+		"[ERR] dedup: message repeated 41 times: connection refused\n" +
+		"[ERR] dedup: retrying\n" +
+		"[ERR] dedup: retrying attempt=2\n" +
+		"[ERR] dedup: message repeated 1 time: retrying attempt=2\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestDedupWindow(t *testing.T) {
+	var out syncBuffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetDedup(10 * time.Millisecond)
+	l.Errorln("down")
+	l.Errorln("down")
+	time.Sleep(50 * time.Millisecond)
+	l.Errorln("down")
+
+	want := "[ERR] down\n[ERR] message repeated 1 time: down\n[ERR] down\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
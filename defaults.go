@@ -8,6 +8,7 @@ import (
 // FlagsDefault provides messages format: "2018/11/26 16:57:49 golog.go:61"
 const FlagsDefault = log.Ldate | log.Ltime | log.Lshortfile
 const customPrefixDefault = "main:"
+const calldepthDefault = 3 // as for log.Logger
 
 var OutDefault = os.Stdout
 var ErrDefault = os.Stderr
@@ -0,0 +1,41 @@
+package golog
+
+import (
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Entry is a single logging event.
+// It's passed to hooks before it's written,
+// so hooks can read or modify it.
+type Entry struct {
+	Level  Level
+	Time   time.Time
+	Prefix string // custom prefix of the logger, e.g. "main:"
+	// Caller is set if the logger's flags contain log.Lshortfile
+	// or log.Llongfile or if the logger has hooks.
+	Caller  runtime.Frame
+	Message string
+	Fields  []Field
+	// Stack is set for entries of the logger's stack level and above
+	// (see SetStackLevel()), it starts from the caller.
+	Stack []runtime.Frame
+}
+
+// callerFrame returns the frame of the caller,
+// skip is counted as in runtime.Callers from the callerFrame's caller.
+func callerFrame(skip int) runtime.Frame {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return runtime.Frame{}
+	}
+	f, _ := runtime.CallersFrames(pcs[:]).Next()
+	return f
+}
+
+// prefixName returns the custom prefix p without
+// trailing spaces and colon: "main:" -> "main".
+func prefixName(p string) string {
+	return strings.TrimSuffix(strings.TrimSpace(p), ":")
+}
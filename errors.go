@@ -0,0 +1,225 @@
+package golog
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// maxErrorDepth limits the depth of error trees to avoid endless cycles.
+const maxErrorDepth = 32
+
+// errorNode is an error of the tree built with errors.Unwrap
+// and Unwrap() []error (errors.Join).
+type errorNode struct {
+	msg    string
+	typ    string
+	stack  []string // "function file:line" from StackTrace(), if any
+	causes []errorNode
+}
+
+// newErrorNode builds the tree of err and its causes.
+func newErrorNode(err error, depth int) errorNode {
+	n := errorNode{
+		msg:   errorString(err),
+		typ:   fmt.Sprintf("%T", err),
+		stack: errorStack(err),
+	}
+	if depth >= maxErrorDepth {
+		return n
+	}
+	for _, cause := range unwrapError(err) {
+		if cause != nil {
+			n.causes = append(n.causes, newErrorNode(cause, depth+1))
+		}
+	}
+	return n
+}
+
+// unwrapError returns causes of err, panics of Unwrap()
+// (e.g. for typed nil errors) are ignored.
+func unwrapError(err error) (causes []error) {
+	defer func() {
+		if recover() != nil {
+			causes = nil
+		}
+	}()
+	switch x := err.(type) {
+	case interface{ Unwrap() []error }:
+		return x.Unwrap()
+	case interface{ Unwrap() error }:
+		return []error{x.Unwrap()}
+	}
+	return nil
+}
+
+// errorString returns err.Error(), panics are recovered as by fmt:
+// typed nil errors (e.g. a nil *MyErr) are rendered as "<nil>".
+func errorString(err error) string {
+	return callString(err, "Error", func() string { return err.Error() })
+}
+
+// stringerString returns s.String(), panics are recovered as by fmt.
+func stringerString(s fmt.Stringer) string {
+	return callString(s, "String", func() string { return s.String() })
+}
+
+// callString returns the result of the method of v called by fn,
+// a panic is rendered as "<nil>" if v is a nil pointer
+// or as "%!v(PANIC=Method method: ...)" otherwise.
+func callString(v interface{}, method string, fn func() string) (s string) {
+	defer func() {
+		if p := recover(); p != nil {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+				s = "<nil>"
+				return
+			}
+			s = fmt.Sprintf("%%!v(PANIC=%s method: %v)", method, p)
+		}
+	}()
+	return fn()
+}
+
+// errorStack returns the stack attached to err as "function file:line"
+// strings. Supported errors have StackTrace() method returning program
+// counters (as filled by runtime.Callers) or runtime.Frame, or they print
+// the stack with %+v verb as github.com/pkg/errors:
+// "message\nfunction\n\tfile:line\nfunction\n\tfile:line...".
+// Panics of the methods (e.g. for typed nil errors) are ignored.
+func errorStack(err error) (stack []string) {
+	defer func() {
+		if recover() != nil {
+			stack = nil
+		}
+	}()
+	switch x := err.(type) {
+	case interface{ StackTrace() []uintptr }:
+		pcs := x.StackTrace()
+		if len(pcs) == 0 {
+			return nil
+		}
+		frames := runtime.CallersFrames(pcs)
+		for {
+			f, more := frames.Next()
+			stack = append(stack, fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line))
+			if !more {
+				return stack
+			}
+		}
+	case interface{ StackTrace() []runtime.Frame }:
+		for _, f := range x.StackTrace() {
+			stack = append(stack, fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line))
+		}
+		return stack
+	case fmt.Formatter:
+		return formattedStack(fmt.Sprintf("%+v", err))
+	}
+	return nil
+}
+
+// formattedStack parses the trailing stack of the error printed
+// with %+v verb: pairs of "function" and "\tfile:line" lines.
+// Wrapping errors of github.com/pkg/errors print their causes first,
+// so the trailing stack belongs to the error itself.
+func formattedStack(s string) []string {
+	lines := strings.Split(s, "\n")
+	i := len(lines)
+	for i >= 3 && isFileLine(lines[i-1]) && !strings.HasPrefix(lines[i-2], "\t") {
+		i -= 2
+	}
+	if i == len(lines) {
+		return nil
+	}
+	stack := make([]string, 0, (len(lines)-i)/2)
+	for ; i < len(lines); i += 2 {
+		stack = append(stack, lines[i]+" "+lines[i+1][1:])
+	}
+	return stack
+}
+
+// isFileLine reports whether s is "\tfile:line".
+func isFileLine(s string) bool {
+	i := strings.LastIndexByte(s, ':')
+	if !strings.HasPrefix(s, "\t") || i < 0 || i == len(s)-1 {
+		return false
+	}
+	for _, c := range s[i+1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hasDetails reports whether the tree has something besides the message.
+func (n *errorNode) hasDetails() bool {
+	return len(n.causes) > 0 || len(n.stack) > 0
+}
+
+// appendText appends the causal chain as indented lines:
+//
+//	caused by: *fs.PathError: open config.yml: no such file or directory
+//		caused by: syscall.Errno: no such file or directory
+//
+// The message of the root error is rendered by the caller.
+func (n *errorNode) appendText(buf []byte, depth int) []byte {
+	indent := strings.Repeat("\t", depth)
+	if depth > 0 {
+		buf = append(buf, '\n')
+		buf = append(buf, indent...)
+		buf = append(buf, "caused by: "...)
+		buf = append(buf, n.typ...)
+		buf = append(buf, ": "...)
+		buf = append(buf, n.msg...)
+	}
+	for _, s := range n.stack {
+		buf = append(buf, '\n')
+		buf = append(buf, indent...)
+		buf = append(buf, "\t\tat "...)
+		buf = append(buf, s...)
+	}
+	for i := range n.causes {
+		buf = n.causes[i].appendText(buf, depth+1)
+	}
+	return buf
+}
+
+// appendJSON appends the tree as a JSON object:
+// {"msg":"...","type":"...","stack":["..."],"causes":[{...}]}.
+func (n *errorNode) appendJSON(buf []byte) []byte {
+	buf = append(buf, `{"msg":`...)
+	buf = appendJSONString(buf, n.msg)
+	buf = append(buf, `,"type":`...)
+	buf = appendJSONString(buf, n.typ)
+	if len(n.stack) > 0 {
+		buf = append(buf, `,"stack":[`...)
+		for i, s := range n.stack {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, s)
+		}
+		buf = append(buf, ']')
+	}
+	if len(n.causes) > 0 {
+		buf = append(buf, `,"causes":[`...)
+		for i := range n.causes {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = n.causes[i].appendJSON(buf)
+		}
+		buf = append(buf, ']')
+	}
+	return append(buf, '}')
+}
+
+// Err prints error message with the error err and key/value pairs.
+// The error is rendered with its causal chain (errors.Unwrap and
+// errors.Join trees) and attached stacks (see errorStack()):
+// as indented lines in text format and as an object in JSON.
+// Error values passed as fields to other methods are rendered the same way.
+func (l *Logger) Err(err error, msg string, keyvals ...interface{}) {
+	l.outputw(LevelError, msg, append([]interface{}{"error", err}, keyvals...))
+}
@@ -0,0 +1,125 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type stackError struct {
+	msg   string
+	stack []uintptr
+}
+
+func newStackError(msg string) *stackError {
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	return &stackError{msg: msg, stack: pcs[:]}
+}
+
+func (e *stackError) Error() string         { return e.msg }
+func (e *stackError) StackTrace() []uintptr { return e.stack }
+
+// pkgError prints the stack with %+v as github.com/pkg/errors.
+type pkgError struct {
+	msg string
+}
+
+func (e *pkgError) Error() string { return e.msg }
+
+func (e *pkgError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s\nmain.load\n\t/app/main.go:12\nmain.main\n\t/app/main.go:5", e.msg)
+		return
+	}
+	fmt.Fprint(s, e.msg)
+}
+
+func TestErrText(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	_, err := os.Open("/nonexistent")
+	err = fmt.Errorf("load config: %w", err)
+	l.Err(err, "Failed", "attempt", 1)
+
+	want := "[ERR] Failed error=\"load config: open /nonexistent: no such file or directory\" attempt=1\n" +
+		"\tcaused by: *fs.PathError: open /nonexistent: no such file or directory\n" +
+		"\t\tcaused by: syscall.Errno: no such file or directory\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	l.Errorw("Plain", "error", errors.New("plain"))
+	if want := "[ERR] Plain error=plain\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestErrJSON(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetFormat(FormatJSON)
+	err := errors.Join(newStackError("first"), errors.New("second"))
+	l.Err(err, "Failed")
+
+	var e struct {
+		Error struct {
+			Msg    string
+			Type   string
+			Causes []struct {
+				Msg   string
+				Type  string
+				Stack []string
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatalf("%v: %s", err, out.String())
+	}
+	if e.Error.Msg != "first\nsecond" || e.Error.Type != "*errors.joinError" || len(e.Error.Causes) != 2 {
+		t.Fatalf("unexpected error: %+v", e.Error)
+	}
+	c := e.Error.Causes[0]
+	if c.Msg != "first" || c.Type != "*golog.stackError" || len(c.Stack) != 1 ||
+		!strings.HasPrefix(c.Stack[0], "github.com/nordborn/golog.TestErrJSON ") {
+		t.Errorf("unexpected cause: %+v", c)
+	}
+}
+
+type nilError struct{ msg string }
+
+func (e *nilError) Error() string { return e.msg }
+
+func TestErrTypedNil(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	var err *nilError
+	l.Err(err, "Failed")
+	l.Errorw("Failed", "err", error(err))
+	want := "[ERR] Failed error=<nil>\n[ERR] Failed err=<nil>\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestErrFormattedStack(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.Err(&pkgError{"bad\nconfig"}, "Failed")
+	want := "[ERR] Failed error=\"bad\\nconfig\"\n" +
+		"\t\tat main.load /app/main.go:12\n" +
+		"\t\tat main.main /app/main.go:5\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,125 @@
+package golog
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+var (
+	exitMu       sync.Mutex
+	exitHandlers []func()
+)
+
+// RegisterExitHandler registers the handler which is called
+// by Fatal(), Fatalln() and Fatalf() of any logger before exit,
+// e.g. to close connections or remove temporary files.
+// Handlers are called in order of registration,
+// their panics are recovered and reported to ErrDefault.
+func RegisterExitHandler(handler func()) {
+	exitMu.Lock()
+	defer exitMu.Unlock()
+	exitHandlers = append(exitHandlers, handler)
+}
+
+func runExitHandlers() {
+	exitMu.Lock()
+	handlers := exitHandlers
+	exitMu.Unlock()
+	for _, h := range handlers {
+		runExitHandler(h)
+	}
+}
+
+func runExitHandler(h func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			fmt.Fprintf(ErrDefault, "golog: exit handler panicked: %v\n", v)
+		}
+	}()
+	h()
+}
+
+// flusher is implemented by buffered outputs and hooks.
+type flusher interface {
+	Flush() error
+}
+
+// syncer is implemented by files (*os.File, *RotatingFile).
+type syncer interface {
+	Sync() error
+}
+
+// flush flushes v if it supports it.
+// Errors are ignored: e.g. Sync() of terminals always fails.
+func flush(v interface{}) {
+	switch f := v.(type) {
+	case flusher:
+		f.Flush()
+	case syncer:
+		f.Sync()
+	}
+}
+
+// flushOutputs flushes outputs and hooks of c which support it,
+// each once: outputs can be shared and hooks of several levels
+// are the same hook.
+func (c *config) flushOutputs() {
+	seen := map[interface{}]bool{}
+	flushOnce := func(v interface{}) {
+		if v == nil {
+			return
+		}
+		// incomparable values (e.g. structs with slices
+		// in interface fields) can't be map keys
+		if reflect.ValueOf(v).Comparable() {
+			if seen[v] {
+				return
+			}
+			seen[v] = true
+		}
+		flush(v)
+	}
+	flushOnce(c.outWriter)
+	flushOnce(c.errWriter)
+	for _, w := range c.levelWriters {
+		flushOnce(w)
+	}
+	for _, hooks := range c.hooks {
+		for _, h := range hooks {
+			flushOnce(h)
+		}
+	}
+}
+
+// exit runs exit handlers, flushes the logger and its outputs
+// and calls the exit function with the exit code.
+func (l *Logger) exit() {
+	runExitHandlers()
+	l.Flush()
+	c := l.config()
+	c.flushOutputs()
+	c.exitFunc(c.exitCode)
+}
+
+// SetExitCode sets the code passed to the exit function
+// by Fatal(), Fatalln() and Fatalf() (1 by default).
+func (l *Logger) SetExitCode(code int) {
+	l.update(func(c *config) {
+		c.exitCode = code
+	})
+}
+
+// SetExitFunc sets the function called by Fatal(), Fatalln() and Fatalf()
+// to stop the process (os.Exit by default), nil restores os.Exit.
+// Tests can use it to check Fatal paths without stopping the test binary,
+// Fatal returns if fn returns.
+func (l *Logger) SetExitFunc(fn func(code int)) {
+	if fn == nil {
+		fn = os.Exit
+	}
+	l.update(func(c *config) {
+		c.exitFunc = fn
+	})
+}
@@ -0,0 +1,102 @@
+package golog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// flushWriter buffers written data until Flush.
+type flushWriter struct {
+	buf, flushed bytes.Buffer
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *flushWriter) Flush() error {
+	_, err := w.buf.WriteTo(&w.flushed)
+	return err
+}
+
+func TestFatalExit(t *testing.T) {
+	defer func() {
+		exitHandlers = nil
+	}()
+	var order []string
+	RegisterExitHandler(func() { order = append(order, "handler") })
+	RegisterExitHandler(func() { panic("ignored") })
+
+	var out flushWriter
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetAsync(AsyncConfig{Size: 8})
+	defer l.Close()
+	code := -1
+	l.SetExitFunc(func(c int) {
+		order = append(order, "exit")
+		code = c
+	})
+	l.SetExitCode(3)
+
+	l.Info("last words")
+	l.Fatal("bye")
+	if code != 3 || strings.Join(order, " ") != "handler exit" {
+		t.Errorf("code %d, order %v", code, order)
+	}
+	want := "[INF] last words\n[FTL] bye\n"
+	if got := out.flushed.String(); got != want {
+		t.Errorf("flushed %q, want %q", got, want)
+	}
+}
+
+// flushCounter counts flushes, it's a writer and a hook of all levels.
+type flushCounter struct {
+	flushes int
+}
+
+func (c *flushCounter) Write(p []byte) (int, error) { return len(p), nil }
+func (c *flushCounter) Levels() []Level             { return AllLevels }
+func (c *flushCounter) Fire(*Entry) error           { return nil }
+func (c *flushCounter) Flush() error {
+	c.flushes++
+	return nil
+}
+
+func TestFatalFlushOnce(t *testing.T) {
+	var out, hook flushCounter
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetLevelOutput(LevelError, &out)
+	l.AddHook(&hook)
+	l.SetExitFunc(func(int) {})
+
+	l.Fatal("bye")
+	if out.flushes != 1 || hook.flushes != 1 {
+		t.Errorf("output flushed %d times, hook flushed %d times", out.flushes, hook.flushes)
+	}
+}
+
+// sliceWriter is a writer of an incomparable type.
+type sliceWriter []byte
+
+func (w sliceWriter) Write(p []byte) (int, error) { return len(p), nil }
+
+// wrapWriter is comparable, but it can hold an incomparable writer.
+type wrapWriter struct {
+	io.Writer
+}
+
+func TestFatalFlushIncomparable(t *testing.T) {
+	l := New("", 0)
+	l.SetOutput(wrapWriter{sliceWriter{}}, wrapWriter{sliceWriter{}})
+	exited := false
+	l.SetExitFunc(func(int) { exited = true })
+
+	l.Fatal("bye")
+	if !exited {
+		t.Error("exit function isn't called")
+	}
+}
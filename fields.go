@@ -0,0 +1,47 @@
+package golog
+
+import (
+	"fmt"
+)
+
+// fieldMissingValue is rendered for a key without a value
+// (odd number of keyvals).
+const fieldMissingValue = "(MISSING)"
+
+// Field is a key/value pair attached to a log entry.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// appendFields converts keyvals (key1, value1, key2, value2, ...)
+// to fields and appends them to dst.
+// Non-string keys are converted with fmt.Sprint.
+func appendFields(dst []Field, keyvals []interface{}) []Field {
+	for i := 0; i < len(keyvals); i += 2 {
+		f := Field{Value: fieldMissingValue}
+		if k, ok := keyvals[i].(string); ok {
+			f.Key = k
+		} else {
+			f.Key = fmt.Sprint(keyvals[i])
+		}
+		if i+1 < len(keyvals) {
+			f.Value = keyvals[i+1]
+		}
+		dst = append(dst, f)
+	}
+	return dst
+}
+
+// With returns a child logger which renders the given key/value pairs
+// (key1, value1, key2, value2, ...) on every entry
+// additionally to the fields of l.
+// The child logger shares configuration (writers, prefix, flags, level etc.)
+// with l: setters called on either of them affect both.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := *l
+	child.calldepth = calldepthDefault
+	// full slice expression prevents children from sharing the tail
+	child.fields = appendFields(l.fields[:len(l.fields):len(l.fields)], keyvals)
+	return &child
+}
@@ -0,0 +1,288 @@
+package golog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// FluentMode defines the mode of the Fluentd forward protocol.
+type FluentMode int
+
+// Modes of the forward protocol:
+// FluentMessage - one message per entry: [tag, time, record] (default);
+// FluentForward - batches of entries: [tag, [[time, record], ...]];
+// FluentPackedForward - batches as a binary stream of entries:
+// [tag, bin([time, record][time, record]...)].
+const (
+	FluentMessage FluentMode = iota
+	FluentForward
+	FluentPackedForward
+)
+
+// Defaults of FluentConfig.
+const (
+	fluentAddrDefault          = "127.0.0.1:24224"
+	fluentTagDefault           = "golog"
+	fluentBufferSizeDefault    = 8192
+	fluentBatchSizeDefault     = 256
+	fluentFlushIntervalDefault = 100 * time.Millisecond
+	fluentTimeoutDefault       = 5 * time.Second
+	fluentMaxBackoff           = 30 * time.Second
+)
+
+// FluentConfig defines the Fluentd or Fluent Bit forward input
+// and the delivery of entries.
+type FluentConfig struct {
+	// Network is "tcp" (default) or "unix".
+	Network string
+	// Addr is "host:port" ("127.0.0.1:24224" by default) or the socket path.
+	Addr string
+	// Tag is the tag of all entries ("golog" by default).
+	Tag  string
+	Mode FluentMode
+	// RequireAck makes the hook wait for acknowledgments of the agent
+	// and resend unacknowledged entries (at-least-once delivery),
+	// otherwise entries written just before a broken connection
+	// is detected can be lost.
+	RequireAck bool
+	// BufferSize is the max number of buffered entries (8192 by default),
+	// the oldest entries are dropped if the buffer is full.
+	BufferSize int
+	// BatchSize is the max number of entries sent at once (256 by default).
+	BatchSize int
+	// FlushInterval is the max delay of buffered entries (100ms by default).
+	FlushInterval time.Duration
+	// Timeout limits dialing, writing and waiting for acknowledgments
+	// (5s by default).
+	Timeout time.Duration
+}
+
+// FluentHook is a hook which sends entries to Fluentd or Fluent Bit
+// with the forward protocol. The record of an entry contains
+// level, prefix, caller ("file:line"), msg, fields and stack.
+// Entries are buffered and sent by a background goroutine,
+// sending is retried with exponential backoff when the agent
+// is unavailable, e.g. while it restarts.
+type FluentHook struct {
+	cfg   FluentConfig
+	batch batcher // of encoded time and record of entries
+
+	// used by the background goroutine only
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewFluentHook returns the hook which sends entries of all levels,
+// add it with AddHook(). Close the hook to send buffered entries
+// and stop its goroutine. The first connection is established
+// by the goroutine, so the agent may start after the hook.
+func NewFluentHook(cfg FluentConfig) *FluentHook {
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = fluentAddrDefault
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = fluentTagDefault
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = fluentBufferSizeDefault
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = fluentBatchSizeDefault
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = fluentFlushIntervalDefault
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = fluentTimeoutDefault
+	}
+	h := &FluentHook{cfg: cfg}
+	h.batch = batcher{
+		name:      "fluent",
+		bufSize:   cfg.BufferSize,
+		batchSize: cfg.BatchSize,
+		interval:  cfg.FlushInterval,
+		timeout:   cfg.Timeout,
+		send:      h.send,
+		stop:      h.disconnect,
+	}
+	h.batch.start(cfg.FlushInterval, fluentMaxBackoff)
+	return h
+}
+
+// Levels implements Hook.
+func (h *FluentHook) Levels() []Level {
+	return AllLevels
+}
+
+// Fire implements Hook, it buffers e.
+func (h *FluentHook) Fire(e *Entry) error {
+	return h.batch.add(appendFluentEvent(nil, e), 1)
+}
+
+// Dropped returns the number of entries dropped because the buffer was full.
+func (h *FluentHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.batch.dropped)
+}
+
+// Flush sends buffered entries, it waits no longer than the timeout.
+func (h *FluentHook) Flush() error {
+	return h.batch.flush()
+}
+
+// Close sends buffered entries (one attempt), closes the connection
+// and stops the background goroutine.
+// Entries fired after Close are not sent.
+func (h *FluentHook) Close() error {
+	return h.batch.close()
+}
+
+// send sends the batch, it returns the number of sent entries.
+func (h *FluentHook) send(batch []batchItem) (int, error) {
+	if h.conn == nil {
+		conn, err := net.DialTimeout(h.cfg.Network, h.cfg.Addr, h.cfg.Timeout)
+		if err != nil {
+			return 0, err
+		}
+		h.conn, h.reader = conn, bufio.NewReader(conn)
+	}
+	events := make([][]byte, len(batch))
+	for i, it := range batch {
+		events[i] = it.value.([]byte)
+	}
+	if h.cfg.Mode == FluentMessage {
+		for i := range events {
+			if err := h.sendMessage(events[i : i+1]); err != nil {
+				h.disconnect()
+				return i, err
+			}
+		}
+		return len(events), nil
+	}
+	if err := h.sendMessage(events); err != nil {
+		h.disconnect()
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// sendMessage sends events in one message of the forward protocol
+// and waits for the acknowledgment if it's required.
+func (h *FluentHook) sendMessage(events [][]byte) error {
+	var chunk string
+	if h.cfg.RequireAck {
+		var id [16]byte
+		if _, err := rand.Read(id[:]); err != nil {
+			return err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+	}
+	msg := h.appendMessage(nil, events, chunk)
+	h.conn.SetDeadline(time.Now().Add(h.cfg.Timeout))
+	if _, err := h.conn.Write(msg); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+	resp, err := readMsgpack(h.reader)
+	if err != nil {
+		return fmt.Errorf("failed to read ack: %v", err)
+	}
+	if m, ok := resp.(map[string]interface{}); !ok || m["ack"] != chunk {
+		return fmt.Errorf("unexpected ack %v", resp)
+	}
+	return nil
+}
+
+// appendMessage appends the message with events in the mode of h
+// to buf, non-empty chunk is sent as the option for acknowledgment.
+func (h *FluentHook) appendMessage(buf []byte, events [][]byte, chunk string) []byte {
+	n := 2
+	if h.cfg.Mode == FluentMessage {
+		n = 3
+	}
+	if chunk != "" {
+		n++
+	}
+	buf = appendMsgpackArray(buf, n)
+	buf = appendMsgpackString(buf, h.cfg.Tag)
+	switch h.cfg.Mode {
+	case FluentMessage:
+		buf = append(buf, events[0]...)
+	case FluentForward:
+		buf = appendMsgpackArray(buf, len(events))
+		for _, ev := range events {
+			buf = appendMsgpackArray(buf, 2)
+			buf = append(buf, ev...)
+		}
+	case FluentPackedForward:
+		var stream []byte
+		for _, ev := range events {
+			stream = appendMsgpackArray(stream, 2)
+			stream = append(stream, ev...)
+		}
+		buf = appendMsgpackBinary(buf, stream)
+	}
+	if chunk != "" {
+		buf = appendMsgpackMap(buf, 2)
+		buf = appendMsgpackString(buf, "chunk")
+		buf = appendMsgpackString(buf, chunk)
+		buf = appendMsgpackString(buf, "size")
+		buf = appendMsgpackInt(buf, int64(len(events)))
+	}
+	return buf
+}
+
+func (h *FluentHook) disconnect() {
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn, h.reader = nil, nil
+	}
+}
+
+// appendFluentEvent appends the time and the record of e to buf.
+func appendFluentEvent(buf []byte, e *Entry) []byte {
+	buf = appendMsgpackEventTime(buf, e.Time)
+	n := 2 + len(e.Fields)
+	prefix := prefixName(e.Prefix)
+	if prefix != "" {
+		n++
+	}
+	if e.Caller.File != "" {
+		n++
+	}
+	if len(e.Stack) > 0 {
+		n++
+	}
+	buf = appendMsgpackMap(buf, n)
+	buf = appendMsgpackString(buf, "level")
+	buf = appendMsgpackString(buf, e.Level.String())
+	if prefix != "" {
+		buf = appendMsgpackString(buf, "prefix")
+		buf = appendMsgpackString(buf, prefix)
+	}
+	if e.Caller.File != "" {
+		buf = appendMsgpackString(buf, "caller")
+		buf = appendMsgpackString(buf, string(appendFrame(nil, e.Caller, log.Lshortfile)))
+	}
+	buf = appendMsgpackString(buf, "msg")
+	buf = appendMsgpackString(buf, e.Message)
+	for _, f := range e.Fields {
+		buf = appendMsgpackString(buf, f.Key)
+		buf = appendMsgpackValue(buf, f.Value)
+	}
+	if len(e.Stack) > 0 {
+		buf = appendMsgpackString(buf, "stack")
+		buf = appendMsgpackValue(buf, stackStrings(e.Stack))
+	}
+	return buf
+}
@@ -0,0 +1,147 @@
+package golog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+// serveFluent accepts connections of the forward protocol at ln,
+// sends received records to the channel and acknowledges chunks.
+func serveFluent(t *testing.T, ln net.Listener) <-chan map[string]interface{} {
+	records := make(chan map[string]interface{}, 100)
+	event := func(v interface{}, rec interface{}) {
+		ts, ok := v.(msgpackExt)
+		if !ok || ts.Type != 0 || len(ts.Data) != 8 ||
+			time.Since(time.Unix(int64(binary.BigEndian.Uint32(ts.Data)), 0)) > time.Minute {
+			t.Errorf("bad event time %v", v)
+		}
+		records <- rec.(map[string]interface{})
+	}
+	handle := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			v, err := readMsgpack(r)
+			if err != nil {
+				return
+			}
+			msg := v.([]interface{})
+			if msg[0] != "app.logs" {
+				t.Errorf("bad tag %v", msg[0])
+			}
+			var opt interface{}
+			switch x := msg[1].(type) {
+			case msgpackExt:
+				event(x, msg[2])
+				if len(msg) > 3 {
+					opt = msg[3]
+				}
+			case []interface{}:
+				for _, ev := range x {
+					ev := ev.([]interface{})
+					event(ev[0], ev[1])
+				}
+				if len(msg) > 2 {
+					opt = msg[2]
+				}
+			case []byte:
+				sr := bufio.NewReader(bytes.NewReader(x))
+				for {
+					ev, err := readMsgpack(sr)
+					if err != nil {
+						break
+					}
+					event(ev.([]interface{})[0], ev.([]interface{})[1])
+				}
+				if len(msg) > 2 {
+					opt = msg[2]
+				}
+			}
+			if opt, ok := opt.(map[string]interface{}); ok {
+				resp := appendMsgpackMap(nil, 1)
+				resp = appendMsgpackString(resp, "ack")
+				resp = appendMsgpackValue(resp, opt["chunk"])
+				conn.Write(resp)
+			}
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return records
+}
+
+func TestFluent(t *testing.T) {
+	for _, mode := range []FluentMode{FluentMessage, FluentForward, FluentPackedForward} {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		records := serveFluent(t, ln)
+		h := NewFluentHook(FluentConfig{Addr: ln.Addr().String(), Tag: "app.logs", Mode: mode, RequireAck: true})
+		l := New("api:", 0)
+		l.SetOutput(ioutil.Discard, ioutil.Discard)
+		l.AddHook(h)
+		l.Infow("first", "n", 1, "ok", true)
+		l.Warning("second")
+		if err := h.Flush(); err != nil {
+			t.Errorf("mode %d: %v", mode, err)
+		}
+		h.Close()
+		ln.Close()
+
+		rec := <-records
+		if rec["level"] != "info" || rec["prefix"] != "api" || rec["msg"] != "first" ||
+			rec["n"] != int64(1) || rec["ok"] != true || rec["caller"] == nil {
+			t.Errorf("mode %d: unexpected record %v", mode, rec)
+		}
+		if rec = <-records; rec["level"] != "warning" || rec["msg"] != "second" {
+			t.Errorf("mode %d: unexpected record %v", mode, rec)
+		}
+	}
+}
+
+func TestFluentRetry(t *testing.T) {
+	// reserve the address of the agent which isn't started yet
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	h := NewFluentHook(FluentConfig{Addr: addr, Tag: "app.logs", Mode: FluentForward, FlushInterval: time.Millisecond})
+	defer h.Close()
+	l := New("", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+	l.Info("buffered")
+	if err := h.Flush(); err == nil {
+		t.Fatal("flush succeeded without the agent")
+	}
+
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	records := serveFluent(t, ln)
+	select {
+	case rec := <-records:
+		if rec["msg"] != "buffered" {
+			t.Errorf("unexpected record %v", rec)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("entry isn't resent")
+	}
+}
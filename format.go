@@ -0,0 +1,127 @@
+package golog
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Format defines the layout of the logger's output.
+type Format int
+
+// Formats of the logger's output:
+// FormatText - "[INF] main: 2018/11/26 16:57:49 main.go:61: Started" (default);
+// FormatJSON - one JSON object per line:
+// {"level":"info","time":"2018-11-26T16:57:49+03:00","prefix":"main","caller":"main.go:61","msg":"Started"};
+// FormatLogfmt - one logfmt line of key=value pairs:
+// level=info ts=2018-11-26T16:57:49+03:00 prefix=main caller=main.go:61 msg=Started.
+// Time and caller are present in structured formats only
+// if the corresponding flags are set.
+const (
+	FormatText Format = iota
+	FormatJSON
+	FormatLogfmt
+)
+
+// encode appends e to buf in the format f.
+func (f Format) encode(buf []byte, e *Entry, flags int) []byte {
+	switch f {
+	case FormatJSON:
+		return appendJSON(buf, e, flags)
+	case FormatLogfmt:
+		return appendLogfmt(buf, e, flags)
+	}
+	return appendText(buf, e, flags)
+}
+
+// appendText appends e to buf in the manner of log.Logger:
+// level prefix, custom prefix, time, caller, message and fields.
+func appendText(buf []byte, e *Entry, flags int) []byte {
+	prefix := e.Level.prefix()
+	if e.Prefix != "" {
+		prefix += e.Prefix + " "
+	}
+	if flags&log.Lmsgprefix == 0 {
+		buf = append(buf, prefix...)
+	}
+	if flags&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
+		t := e.Time
+		if flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		if flags&log.Ldate != 0 {
+			buf = t.AppendFormat(buf, "2006/01/02 ")
+		}
+		if flags&log.Lmicroseconds != 0 {
+			buf = t.AppendFormat(buf, "15:04:05.000000 ")
+		} else if flags&log.Ltime != 0 {
+			buf = t.AppendFormat(buf, "15:04:05 ")
+		}
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		buf = appendCaller(buf, e, flags)
+		buf = append(buf, ": "...)
+	}
+	if flags&log.Lmsgprefix != 0 {
+		buf = append(buf, prefix...)
+	}
+	buf = appendMessageText(buf, e)
+	return append(buf, '\n')
+}
+
+// appendMessageText appends the message of e to buf with fields,
+// causal chains of errors and the stack in the text layout.
+func appendMessageText(buf []byte, e *Entry) []byte {
+	buf = append(buf, e.Message...)
+	var errs []errorNode
+	for _, f := range e.Fields {
+		buf = append(buf, ' ')
+		buf = append(buf, f.Key...)
+		buf = append(buf, '=')
+		buf = append(buf, quoteIfNeeded(fmt.Sprint(f.Value))...)
+		if err, ok := f.Value.(error); ok && err != nil {
+			if n := newErrorNode(err, 0); n.hasDetails() {
+				errs = append(errs, n)
+			}
+		}
+	}
+	// causal chains of errors are rendered after the line
+	for i := range errs {
+		buf = errs[i].appendText(buf, 0)
+	}
+	return appendStackText(buf, e.Stack)
+}
+
+// appendCaller appends "file:line" of the caller of e to buf,
+// file is short or long according to flags.
+func appendCaller(buf []byte, e *Entry, flags int) []byte {
+	return appendFrame(buf, e.Caller, flags)
+}
+
+// appendTime appends time of e to buf in RFC3339 format
+// (with microseconds if log.Lmicroseconds is set).
+func appendTime(buf []byte, e *Entry, flags int) []byte {
+	t := e.Time
+	if flags&log.LUTC != 0 {
+		t = t.UTC()
+	}
+	if flags&log.Lmicroseconds != 0 {
+		return t.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+	}
+	return t.AppendFormat(buf, time.RFC3339)
+}
+
+// quoteIfNeeded quotes s if it's empty or contains spaces,
+// quotes, '=' or non-printable characters.
+func quoteIfNeeded(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || !strconv.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,287 @@
+package golog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GELFCompression defines the compression of GELF messages sent over UDP.
+type GELFCompression int
+
+// Compressions of GELF messages.
+const (
+	GELFCompressNone GELFCompression = iota
+	GELFCompressGzip
+	GELFCompressZlib
+)
+
+const (
+	// gelfChunkSizeDefault fits into the Ethernet MTU with IP and UDP headers.
+	gelfChunkSizeDefault = 1420
+	// gelfChunkHeader is the size of the chunk header:
+	// magic bytes, message ID, sequence number and count.
+	gelfChunkHeader = 12
+	gelfMaxChunks   = 128
+	gelfTimeout     = 5 * time.Second
+)
+
+// GELFConfig defines the Graylog input and the messages' layout.
+type GELFConfig struct {
+	// Network is "udp" (default) or "tcp".
+	Network string
+	// Addr is "host:port" of the GELF input.
+	Addr string
+	// Compression is applied to UDP messages only:
+	// TCP messages are delimited by null bytes, so they can't be compressed.
+	Compression GELFCompression
+	// ChunkSize is the max size of UDP datagrams (1420 by default),
+	// larger messages are chunked.
+	ChunkSize int
+	// Host is os.Hostname() by default.
+	Host string
+}
+
+// GELFHook is a hook which sends entries to Graylog in GELF 1.1.
+// Levels are mapped to syslog severities as by SyslogHook,
+// the custom prefix, the caller and fields are sent as additional
+// fields: _prefix, _file, _line, _func and "_"+key,
+// numeric fields are sent as numbers, other fields as strings.
+// Stacks and causal chains of errors are sent in full_message.
+// Connections are reestablished after errors as by SyslogHook.
+type GELFHook struct {
+	cfg     GELFConfig
+	dropped uint64 // atomic
+	mu      sync.Mutex
+	conn    net.Conn
+	tcp     bool
+	closed  bool
+	retry   backoff
+}
+
+// NewGELFHook connects to the GELF input and returns the hook
+// which sends entries of all levels, add it with AddHook().
+func NewGELFHook(cfg GELFConfig) (*GELFHook, error) {
+	if cfg.Network == "" {
+		cfg.Network = "udp"
+	}
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = gelfChunkSizeDefault
+	}
+	if cfg.ChunkSize <= gelfChunkHeader {
+		return nil, fmt.Errorf("golog: too small GELF chunk size %d", cfg.ChunkSize)
+	}
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+	h := &GELFHook{
+		cfg:   cfg,
+		tcp:   strings.HasPrefix(cfg.Network, "tcp"),
+		retry: backoff{min: reconnectMinBackoff, max: reconnectMaxBackoff},
+	}
+	if err := h.connect(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// connect dials the GELF input, h.mu must be held.
+func (h *GELFHook) connect() error {
+	conn, err := net.DialTimeout(h.cfg.Network, h.cfg.Addr, gelfTimeout)
+	if err != nil {
+		return fmt.Errorf("golog: failed to connect to GELF input: %v", err)
+	}
+	h.conn = conn
+	return nil
+}
+
+// Levels implements Hook.
+func (h *GELFHook) Levels() []Level {
+	return AllLevels
+}
+
+// Fire implements Hook.
+func (h *GELFHook) Fire(e *Entry) error {
+	msg := h.format(nil, e)
+	if h.tcp {
+		msg = append(msg, 0)
+	} else {
+		var err error
+		if msg, err = h.compress(msg); err != nil {
+			return err
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	if h.conn != nil {
+		if h.write(msg) == nil {
+			return nil
+		}
+		h.conn.Close()
+		h.conn = nil
+	}
+	if h.retry.wait() {
+		atomic.AddUint64(&h.dropped, 1)
+		return nil
+	}
+	if err := h.connect(); err != nil {
+		h.retry.failed()
+		atomic.AddUint64(&h.dropped, 1)
+		return err
+	}
+	h.retry.reset()
+	return h.write(msg)
+}
+
+// Dropped returns the number of entries dropped
+// because the GELF input was unavailable.
+func (h *GELFHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// write writes msg to the connection, UDP messages
+// larger than the chunk size are chunked, h.mu must be held.
+func (h *GELFHook) write(msg []byte) error {
+	h.conn.SetWriteDeadline(time.Now().Add(gelfTimeout))
+	if h.tcp || len(msg) <= h.cfg.ChunkSize {
+		_, err := h.conn.Write(msg)
+		return err
+	}
+	size := h.cfg.ChunkSize - gelfChunkHeader
+	count := (len(msg) + size - 1) / size
+	if count > gelfMaxChunks {
+		return fmt.Errorf("golog: GELF message of %d bytes is too large", len(msg))
+	}
+	chunk := make([]byte, gelfChunkHeader, h.cfg.ChunkSize)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	chunk[11] = byte(count)
+	for i := 0; i < count; i++ {
+		chunk[10] = byte(i)
+		data := msg[i*size:]
+		if len(data) > size {
+			data = data[:size]
+		}
+		if _, err := h.conn.Write(append(chunk[:gelfChunkHeader], data...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress compresses msg according to the configuration.
+func (h *GELFHook) compress(msg []byte) ([]byte, error) {
+	var b bytes.Buffer
+	var w io.WriteCloser
+	switch h.cfg.Compression {
+	case GELFCompressGzip:
+		w = gzip.NewWriter(&b)
+	case GELFCompressZlib:
+		w = zlib.NewWriter(&b)
+	default:
+		return msg, nil
+	}
+	if _, err := w.Write(msg); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Close closes the connection, entries fired after Close are not sent.
+func (h *GELFHook) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+	if h.conn == nil {
+		return nil
+	}
+	return h.conn.Close()
+}
+
+// format appends e to buf as a GELF JSON object.
+func (h *GELFHook) format(buf []byte, e *Entry) []byte {
+	buf = append(buf, `{"version":"1.1","host":`...)
+	buf = appendJSONString(buf, h.cfg.Host)
+	buf = append(buf, `,"short_message":`...)
+	buf = appendJSONString(buf, e.Message)
+	if full := appendMessageText(nil, e); bytes.IndexByte(full, '\n') >= 0 {
+		buf = append(buf, `,"full_message":`...)
+		buf = appendJSONString(buf, string(full))
+	}
+	buf = append(buf, `,"timestamp":`...)
+	buf = strconv.AppendFloat(buf, float64(e.Time.UnixNano()/1e3)/1e6, 'f', 6, 64)
+	buf = append(buf, `,"level":`...)
+	buf = strconv.AppendInt(buf, int64(syslogSeverity(e.Level)), 10)
+	if p := prefixName(e.Prefix); p != "" {
+		buf = append(buf, `,"_prefix":`...)
+		buf = appendJSONString(buf, p)
+	}
+	if e.Caller.File != "" {
+		buf = append(buf, `,"_file":`...)
+		buf = appendJSONString(buf, e.Caller.File)
+		buf = append(buf, `,"_line":`...)
+		buf = strconv.AppendInt(buf, int64(e.Caller.Line), 10)
+		buf = append(buf, `,"_func":`...)
+		buf = appendJSONString(buf, e.Caller.Function)
+	}
+	for _, f := range e.Fields {
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, gelfKey(f.Key))
+		buf = append(buf, ':')
+		buf = appendGELFValue(buf, f.Value)
+	}
+	return append(buf, '}')
+}
+
+// gelfKey converts k to the name of the additional field:
+// "_" + k with characters other than letters, digits,
+// '_', '.' and '-' replaced with '_'.
+// "_id" is reserved by Graylog, so "id" is sent as "__id".
+func gelfKey(k string) string {
+	if k == "id" {
+		return "__id"
+	}
+	b := make([]byte, 1, len(k)+1)
+	b[0] = '_'
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == '_' || c == '.' || c == '-') {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
+
+// appendGELFValue appends v to buf as a number if it's numeric
+// or as a string otherwise: GELF supports only these types.
+func appendGELFValue(buf []byte, v interface{}) []byte {
+	switch v.(type) {
+	case int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, float32, float64:
+		return appendJSONValue(buf, v)
+	}
+	return appendJSONString(buf, fmt.Sprint(v))
+}
@@ -0,0 +1,184 @@
+package golog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGELFUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	read := func() []byte {
+		buf := make([]byte, 2048)
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf[:n]
+	}
+
+	h, err := NewGELFHook(GELFConfig{
+		Addr:        pc.LocalAddr().String(),
+		Compression: GELFCompressZlib,
+		ChunkSize:   100,
+		Host:        "web1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := New("api:", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+
+	// random text to keep compressed message larger than one chunk
+	var long strings.Builder
+	for i := 0; long.Len() < 1000; i++ {
+		long.WriteString(strings.Repeat(string(rune('a'+i*7%26)), i%5+1))
+		long.WriteByte(byte('0' + i*13%10))
+	}
+	l.Errorw(long.String(), "id", 42, "user name", "bob", "err", errors.New("failed"))
+
+	var msg []byte
+	for i, count := 0, 1; i < count; i++ {
+		chunk := read()
+		if chunk[0] != 0x1e || chunk[1] != 0x0f || int(chunk[10]) != i || len(chunk) > 100 {
+			t.Fatalf("bad chunk %d header: %x", i, chunk[:12])
+		}
+		count = int(chunk[11])
+		if count < 2 {
+			t.Fatalf("message isn't chunked")
+		}
+		msg = append(msg, chunk[12:]...)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err = json.NewDecoder(zr).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"version":       "1.1",
+		"host":          "web1",
+		"short_message": long.String(),
+		"level":         3.0,
+		"_prefix":       "api",
+		"_func":         "github.com/nordborn/golog.TestGELFUDP",
+		"__id":          42.0,
+		"_user_name":    "bob",
+		"_err":          "failed",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s=%v, want %v", k, got[k], v)
+		}
+	}
+	if _, ok := got["timestamp"].(float64); !ok || !strings.HasSuffix(got["_file"].(string), "gelf_test.go") {
+		t.Errorf("unexpected message: %v", got)
+	}
+}
+
+func TestGELFTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	h, err := NewGELFHook(GELFConfig{Network: "tcp", Addr: ln.Addr().String(), Compression: GELFCompressGzip})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	l := New("", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.SetStackLevel(LevelCritical)
+	l.AddHook(h)
+	l.Info("one")
+	l.Critical("two")
+
+	r := bufio.NewReader(conn)
+	for _, want := range []string{"one", "two"} {
+		b, err := r.ReadBytes(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := gzip.NewReader(bytes.NewReader(b)); err == nil {
+			t.Fatal("TCP message is compressed")
+		}
+		var got struct {
+			ShortMessage string `json:"short_message"`
+			FullMessage  string `json:"full_message"`
+		}
+		if err := json.Unmarshal(b[:len(b)-1], &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.ShortMessage != want || (want == "two") != strings.Contains(got.FullMessage, "TestGELFTCP") {
+			t.Errorf("unexpected message %+v", got)
+		}
+	}
+}
+
+func TestGELFReconnectBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	h, err := NewGELFHook(GELFConfig{Network: "tcp", Addr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	// the input is stopped
+	ln.Close()
+	h.mu.Lock()
+	h.conn.Close()
+	h.mu.Unlock()
+	if err := h.Fire(&Entry{Message: "failed"}); err == nil {
+		t.Error("failed reconnection isn't reported")
+	}
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// the next attempt is delayed
+	if err := h.Fire(&Entry{Message: "dropped"}); err != nil || h.Dropped() != 2 {
+		t.Errorf("%v, %d dropped", err, h.Dropped())
+	}
+	h.mu.Lock()
+	h.retry.retryAt = time.Now()
+	h.mu.Unlock()
+	if err := h.Fire(&Entry{Message: "sent"}); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	b, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil || !strings.Contains(string(b), `"short_message":"sent"`) {
+		t.Errorf("got %q, %v", b, err)
+	}
+}
@@ -1 +1,3 @@
 module github.com/nordborn/golog
+
+go 1.21
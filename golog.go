@@ -6,17 +6,22 @@
 //- info;
 //- warning;
 //- error;
-//- critical.
+//- critical;
+//- panic;
+//- fatal.
 //2. Different outputs:
-//- for info-like messages (Trace, Debug, Info), they use os.Stdout by default;
-//- for error-like messages (Warning, Error, Critical, Panic, Fatal) they use os.Stderr by default.
+//- for info-like messages (Trace, Debug, Info, Warning), they use os.Stdout by default;
+//- for error-like messages (Error, Critical, Panic, Fatal) they use os.Stderr by default.
 //
 //You can set:
-//1. logging level (LevelTrace, LevelDebug, LevelInfo, LevelWarning, LevelError, LevelCritical);
+//1. logging level (LevelTrace, LevelDebug, LevelInfo, LevelWarning, LevelError, LevelCritical,
+//LevelPanic, LevelFatal, LevelOff), it can be parsed from config with ParseLevel();
 //2. custom prefix (e.g. "[myapp]: ") additionally to level prefixes ("[main]: " by default);
 //3. output file-like interfaces:
-// - l.outWriter for Trace-Info (os.Stdout by default);
-// - l.errWriter for Error-Fatal (os.Stderr by default).
+// - l.outWriter for Trace-Warning (os.Stdout by default);
+// - l.errWriter for Error-Fatal (os.Stderr by default);
+// the split level can be changed with SetErrThreshold()
+// and any level can be routed to its own writers with SetLevelOutput().
 //
 //You can set flag similar to "log" from standard library for time and file information
 //(default provides "2018/11/26 16:57:49 golog.go:61").
@@ -32,7 +37,9 @@
 package golog
 
 import (
+	"context"
 	"io"
+	"time"
 )
 
 // will be used in package-level logging functions
@@ -56,15 +63,22 @@ func SetFlags(f int) {
 // - info;
 // - warning;
 // - error;
-// - critical.
+// - critical;
+// - panic;
+// - fatal.
 // Use:
 // LevelTrace - to display all messages;
 // LevelDebug - to display debug messages and above;
 // LevelInfo - to display info messages and above;
 // LevelWarning - to display warning messages and above;
-// LevelError - to display error messages and above.
+// LevelError - to display error messages and above;
+// LevelCritical - to display critical messages and above;
+// LevelPanic - to display panic and fatal messages;
+// LevelFatal - to display fatal messages only;
+// LevelOff - to suppress all messages
+// (Panic and Fatal still call panic() and os.Exit()).
 // Default level: LevelTrace
-func SetLevel(level levelType) {
+func SetLevel(level Level) {
 	loggerGlobal.SetLevel(level)
 }
 
@@ -74,8 +88,113 @@ func SetOutput(out, err io.Writer) {
 	loggerGlobal.SetOutput(out, err)
 }
 
+// AddContextExtractor adds the extractor to the global logger,
+// it's called for every entry logged with *Context() functions.
+func AddContextExtractor(fn ContextExtractor) {
+	loggerGlobal.AddContextExtractor(fn)
+}
+
+// SetLevelOutput sets the output destinations for messages of the level
+// of the global logger instead of outWriter/errWriter.
+// Several writers get the same messages (tee),
+// no writers resets the level to the out/err split.
+func SetLevelOutput(level Level, ws ...io.Writer) {
+	loggerGlobal.SetLevelOutput(level, ws...)
+}
+
+// SetErrThreshold sets the lowest level of messages of the global logger
+// which are written to errWriter (LevelError by default).
+func SetErrThreshold(level Level) {
+	loggerGlobal.SetErrThreshold(level)
+}
+
+// AddHook adds the hook to the global logger.
+func AddHook(h Hook) {
+	loggerGlobal.AddHook(h)
+}
+
+// SetAsync switches the global logger to the async mode,
+// see Logger.SetAsync().
+func SetAsync(cfg AsyncConfig) {
+	loggerGlobal.SetAsync(cfg)
+}
+
+// Flush writes summaries of pending duplicate entries of the global logger
+// and waits until all entries queued in the async mode are written.
+func Flush() {
+	loggerGlobal.Flush()
+}
+
+// Close writes summaries of pending duplicate entries and all entries
+// queued by the global logger in the async mode
+// and returns it to the synchronous mode.
+func Close() {
+	loggerGlobal.Close()
+}
+
+// Dropped returns the number of entries dropped by the async queue
+// of the global logger.
+func Dropped() uint64 {
+	return loggerGlobal.Dropped()
+}
+
+// SetSampling enables sampling of the global logger's entries,
+// nil disables it.
+func SetSampling(cfg *SamplingConfig) {
+	loggerGlobal.SetSampling(cfg)
+}
+
+// SampledOut returns the number of the global logger's entries
+// suppressed by sampling.
+func SampledOut() uint64 {
+	return loggerGlobal.SampledOut()
+}
+
+// SetDedup enables suppression of identical entries of the global logger
+// within the window, see Logger.SetDedup().
+func SetDedup(window time.Duration) {
+	loggerGlobal.SetDedup(window)
+}
+
+// SetStackLevel makes entries of the global logger of the level and above
+// include the stack trace of the calling goroutine (LevelOff by default).
+func SetStackLevel(level Level) {
+	loggerGlobal.SetStackLevel(level)
+}
+
+// SetExitCode sets the code passed to the exit function
+// by Fatal(), Fatalln() and Fatalf() of the global logger (1 by default).
+func SetExitCode(code int) {
+	loggerGlobal.SetExitCode(code)
+}
+
+// SetExitFunc sets the function called by Fatal(), Fatalln() and Fatalf()
+// of the global logger to stop the process (os.Exit by default).
+func SetExitFunc(fn func(code int)) {
+	loggerGlobal.SetExitFunc(fn)
+}
+
+// Snapshot saves the configuration of the global logger,
+// restore() brings it back, e.g. `defer golog.Snapshot()()` in tests.
+func Snapshot() (restore func()) {
+	return loggerGlobal.Snapshot()
+}
+
+// SetFormat sets the output format for the global logger
+// (FormatText by default).
+func SetFormat(f Format) {
+	loggerGlobal.SetFormat(f)
+}
+
+// With returns a child logger of the global logger which renders
+// the given key/value pairs (key1, value1, key2, value2, ...) on every entry.
+// The child logger shares configuration (writers, prefix, flags, level etc.)
+// with the global logger.
+func With(keyvals ...interface{}) *Logger {
+	return loggerGlobal.With(keyvals...)
+}
+
 // Trace prints trace message to loggerGlobal.outWriter.
-// Trace calls l.traceLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use trace messages for developing process to trace
 // function calls.
@@ -84,7 +203,6 @@ func Trace(v ...interface{}) {
 }
 
 // Traceln prints trace message to loggerGlobal.outWriter.
-// Traceln calls l.traceLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use trace messages for developing process to trace
 // function calls.
@@ -93,7 +211,6 @@ func Traceln(v ...interface{}) {
 }
 
 // Tracef prints trace message to loggerGlobal.outWriter.
-// Tracef calls l.traceLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use trace messages for developing process to trace
 // function calls.
@@ -101,8 +218,23 @@ func Tracef(format string, v ...interface{}) {
 	loggerGlobal.Tracef(format, v...)
 }
 
+// Tracew prints trace message with key/value pairs to loggerGlobal.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use trace messages for developing process to trace
+// function calls.
+func Tracew(msg string, keyvals ...interface{}) {
+	loggerGlobal.Tracew(msg, keyvals...)
+}
+
+// TraceContext prints trace message to loggerGlobal.outWriter
+// with fields extracted from ctx by the global logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func TraceContext(ctx context.Context, v ...interface{}) {
+	loggerGlobal.TraceContext(ctx, v...)
+}
+
 // Debug prints debug message to loggerGlobal.outWriter.
-// Debug calls l.debugLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use debug messages to debug your business logic.
 func Debug(v ...interface{}) {
@@ -110,7 +242,6 @@ func Debug(v ...interface{}) {
 }
 
 // Debugln prints debug message to loggerGlobal.outWriter.
-// Debugln calls l.debugLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use debug messages to debug your business logic.
 func Debugln(v ...interface{}) {
@@ -118,15 +249,28 @@ func Debugln(v ...interface{}) {
 }
 
 // Debugf prints debug message to loggerGlobal.outWriter.
-// Debugf calls l.debugLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use debug messages to debug your business logic.
 func Debugf(format string, v ...interface{}) {
 	loggerGlobal.Debugf(format, v...)
 }
 
+// Debugw prints debug message with key/value pairs to loggerGlobal.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use debug messages to debug your business logic.
+func Debugw(msg string, keyvals ...interface{}) {
+	loggerGlobal.Debugw(msg, keyvals...)
+}
+
+// DebugContext prints debug message to loggerGlobal.outWriter
+// with fields extracted from ctx by the global logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func DebugContext(ctx context.Context, v ...interface{}) {
+	loggerGlobal.DebugContext(ctx, v...)
+}
+
 // Info prints info message to loggerGlobal.outWriter.
-// Info calls l.infoLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use info messages for common information.
 func Info(v ...interface{}) {
@@ -134,7 +278,6 @@ func Info(v ...interface{}) {
 }
 
 // Infoln prints info message to loggerGlobal.outWriter.
-// Infoln calls l.infoLogger to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use info messages for common information.
 func Infoln(v ...interface{}) {
@@ -142,13 +285,27 @@ func Infoln(v ...interface{}) {
 }
 
 // Infof prints info message to loggerGlobal.outWriter.
-// Infof calls l.infoLogger to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use info messages for common information.
 func Infof(format string, v ...interface{}) {
 	loggerGlobal.Infof(format, v...)
 }
 
+// Infow prints info message with key/value pairs to loggerGlobal.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use info messages for common information.
+func Infow(msg string, keyvals ...interface{}) {
+	loggerGlobal.Infow(msg, keyvals...)
+}
+
+// InfoContext prints info message to loggerGlobal.outWriter
+// with fields extracted from ctx by the global logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func InfoContext(ctx context.Context, v ...interface{}) {
+	loggerGlobal.InfoContext(ctx, v...)
+}
+
 // Print is equivalent to loggerGlobal.Info()
 func Print(v ...interface{}) {
 	loggerGlobal.Print(v...)
@@ -164,8 +321,15 @@ func Printf(format string, v ...interface{}) {
 	loggerGlobal.Printf(format, v...)
 }
 
+// Logw prints message with key/value pairs at the level
+// which is known only at runtime (e.g. in middlewares).
+// It doesn't call panic() and os.Exit() for LevelPanic and LevelFatal,
+// messages of invalid levels (e.g. LevelOff) are dropped.
+func Logw(level Level, msg string, keyvals ...interface{}) {
+	loggerGlobal.Logw(level, msg, keyvals...)
+}
+
 // Warning prints warning message to loggerGlobal.outWriter.
-// Warning calls l.warningLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use warning messages for handled errors which don't brake
 // business logic but should be noted (mostly for developers).
@@ -174,7 +338,6 @@ func Warning(v ...interface{}) {
 }
 
 // Warningln prints warning message to loggerGlobal.outWriter.
-// Warningln calls l.warningLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use warning messages for handled errors which don't brake
 // business logic but should be noted (mostly for developers).
@@ -183,7 +346,6 @@ func Warningln(v ...interface{}) {
 }
 
 // Warningf prints warning message to loggerGlobal.outWriter.
-// Warningf calls l.warningLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use warning messages for handled errors which don't brake
 // business logic but should be noted (mostly for developers).
@@ -191,8 +353,23 @@ func Warningf(format string, v ...interface{}) {
 	loggerGlobal.Warningf(format, v...)
 }
 
+// Warningw prints warning message with key/value pairs to loggerGlobal.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use warning messages for handled errors which don't brake
+// business logic but should be noted (mostly for developers).
+func Warningw(msg string, keyvals ...interface{}) {
+	loggerGlobal.Warningw(msg, keyvals...)
+}
+
+// WarningContext prints warning message to loggerGlobal.outWriter
+// with fields extracted from ctx by the global logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func WarningContext(ctx context.Context, v ...interface{}) {
+	loggerGlobal.WarningContext(ctx, v...)
+}
+
 // Error prints info message to loggerGlobal.errWriter.
-// Error calls l.errorLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use error messages for errors which mostly don't brake
 // business logic.
@@ -201,7 +378,6 @@ func Error(v ...interface{}) {
 }
 
 // Errorln prints info message to loggerGlobal.errWriter.
-// Errorln calls l.errorLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use error messages for errors which mostly don't brake
 // business logic.
@@ -210,7 +386,6 @@ func Errorln(v ...interface{}) {
 }
 
 // Errorf prints info message to loggerGlobal.errWriter.
-// Errorf calls l.errorLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use error messages for errors which mostly don't brake
 // business logic.
@@ -218,8 +393,29 @@ func Errorf(format string, v ...interface{}) {
 	loggerGlobal.Errorf(format, v...)
 }
 
+// Errorw prints error message with key/value pairs to loggerGlobal.errWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use error messages for errors which mostly don't brake
+// business logic.
+func Errorw(msg string, keyvals ...interface{}) {
+	loggerGlobal.Errorw(msg, keyvals...)
+}
+
+// ErrorContext prints error message to loggerGlobal.errWriter
+// with fields extracted from ctx by the global logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func ErrorContext(ctx context.Context, v ...interface{}) {
+	loggerGlobal.ErrorContext(ctx, v...)
+}
+
+// Err prints error message with the error err and key/value pairs
+// to loggerGlobal.errWriter, see Logger.Err().
+func Err(err error, msg string, keyvals ...interface{}) {
+	loggerGlobal.Err(err, msg, keyvals...)
+}
+
 // Critical prints critical message to loggerGlobal.errWriter.
-// Critical calls l.criticalLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use critical messages for errors which may brake
 // business logic.
@@ -228,7 +424,6 @@ func Critical(v ...interface{}) {
 }
 
 // Criticalln prints critical message to loggerGlobal.errWriter.
-// Criticalln calls l.criticalLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use critical messages for errors which may brake
 // business logic.
@@ -237,7 +432,6 @@ func Criticalln(v ...interface{}) {
 }
 
 // Criticalf prints critical message to loggerGlobal.errWriter.
-// Criticalf calls l.criticalLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use critical messages for errors which may brake
 // business logic.
@@ -245,6 +439,22 @@ func Criticalf(format string, v ...interface{}) {
 	loggerGlobal.Criticalf(format, v...)
 }
 
+// Criticalw prints critical message with key/value pairs to loggerGlobal.errWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use critical messages for errors which may brake
+// business logic.
+func Criticalw(msg string, keyvals ...interface{}) {
+	loggerGlobal.Criticalw(msg, keyvals...)
+}
+
+// CriticalContext prints critical message to loggerGlobal.errWriter
+// with fields extracted from ctx by the global logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func CriticalContext(ctx context.Context, v ...interface{}) {
+	loggerGlobal.CriticalContext(ctx, v...)
+}
+
 // Panic is equivalent to loggerGlobal.Critical() followed by a call to panic().
 func Panic(v ...interface{}) {
 	loggerGlobal.Panic(v...)
@@ -260,25 +470,50 @@ func Panicf(format string, v ...interface{}) {
 	loggerGlobal.Panicf(format, v...)
 }
 
-// Fatal prints fatal message to loggerGlobal.errWriter.
-// Fatal calls l.fatalLogger.Print to print to the logger
-// followed by a call to os.Exit(1).
+// Recover recovers a panic and logs its value with the full stack
+// with the global logger as a panic message ([PNC] prefix).
+// It must be deferred directly: defer golog.Recover().
+func Recover() {
+	if v := recover(); v != nil {
+		loggerGlobal.logRecovered(v, panicStack())
+	}
+}
+
+// RecoverWith recovers a panic, logs its value with the full stack
+// with the global logger and calls fn with the value.
+// It must be deferred directly: defer golog.RecoverWith(fn).
+func RecoverWith(fn func(v interface{})) {
+	if v := recover(); v != nil {
+		loggerGlobal.logRecovered(v, panicStack())
+		fn(v)
+	}
+}
+
+// Go runs fn in a new goroutine which recovers panics of fn
+// and logs them with the global logger.
+func Go(fn func()) {
+	loggerGlobal.Go(fn)
+}
+
+// Fatal prints fatal message to loggerGlobal.errWriter
+// followed by calls of exit handlers, flush of outputs
+// and a call to the exit function (os.Exit(1) by default).
 // Note: recover() can't intercept Fatal.
 func Fatal(v ...interface{}) {
 	loggerGlobal.Fatal(v...)
 }
 
-// Fatalln prints fatal message to loggerGlobal.errWriter.
-// Fatalln calls l.fatalLogger.Print to print to the logger
-// followed by a call to os.Exit(1).
+// Fatalln prints fatal message to loggerGlobal.errWriter
+// followed by calls of exit handlers, flush of outputs
+// and a call to the exit function (os.Exit(1) by default).
 // Note: recover() can't intercept Fatalf.
 func Fatalln(v ...interface{}) {
 	loggerGlobal.Fatalln(v...)
 }
 
-// Fatalf prints fatal message to loggerGlobal.errWriter.
-// Fatalf calls l.fatalLogger.Print to print to the logger
-// followed by a call to os.Exit(1).
+// Fatalf prints fatal message to loggerGlobal.errWriter
+// followed by calls of exit handlers, flush of outputs
+// and a call to the exit function (os.Exit(1) by default).
 // Note: recover() can't intercept Fatalf.
 func Fatalf(format string, v ...interface{}) {
 	loggerGlobal.Fatalf(format, v...)
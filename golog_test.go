@@ -1,8 +1,12 @@
 package golog
 
 import (
+	"bytes"
 	"log"
+	"runtime"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestStdLog(t *testing.T) {
@@ -48,3 +52,155 @@ func TestLogger(t *testing.T) {
 	l.SetLevel(LevelError)
 	l.Infoln("You shouldn't see info")
 }
+
+func TestWith(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := New("fields:", 0)
+	l.SetOutput(&out, &errOut)
+	child := l.With("request_id", 42, "user", "john doe")
+	child.Infoln("Started")
+	child.Errorw("Failed", "duration", time.Second, "odd")
+	l.Infow("Parent", "k", "v")
+
+	want := "[INF] fields: Started request_id=42 user=\"john doe\"\n" +
+		"[INF] fields: Parent k=v\n"
+	if out.String() != want {
+		t.Errorf("out: got %q, want %q", out.String(), want)
+	}
+	want = "[ERR] fields: Failed request_id=42 user=\"john doe\" duration=1s odd=(MISSING)\n"
+	if errOut.String() != want {
+		t.Errorf("err: got %q, want %q", errOut.String(), want)
+	}
+}
+
+func TestLogwInvalidLevel(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.Logw(LevelOff, "off")
+	l.Logw(Level(42), "unknown")
+	l.Logw(LevelWarning, "warning")
+
+	if want := "[WRN] warning\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := New("json:", log.Lshortfile)
+	l.SetOutput(&out, &errOut)
+	l.SetFormat(FormatJSON)
+	_, _, line, _ := runtime.Caller(0)
+	l.With("user", "john \"doe\"\n").Infow("Started", "n", 1, "ok", true, "d", time.Second, "nil", nil)
+	l.Errorln("Failed", "<tab>\t")
+
+	want := `{"level":"info","prefix":"json","caller":"golog_test.go:` + strconv.Itoa(line+1) + `","msg":"Started",` +
+		`"user":"john \"doe\"\n","n":1,"ok":true,"d":"1s","nil":null}` + "\n"
+	if out.String() != want {
+		t.Errorf("out: got %s, want %s", out.String(), want)
+	}
+	want = `{"level":"error","prefix":"json","caller":"golog_test.go:` + strconv.Itoa(line+2) + `","msg":"Failed <tab>\t"}` + "\n"
+	if errOut.String() != want {
+		t.Errorf("err: got %s, want %s", errOut.String(), want)
+	}
+}
+
+func TestFormatLogfmt(t *testing.T) {
+	var out bytes.Buffer
+	l := New("logfmt:", 0)
+	l.SetOutput(&out, &out)
+	l.SetFormat(FormatLogfmt)
+	l.Infow("Started server", "addr", ":8080", "bad key", "a=b", "quote", `say "hi"`, "nl", "a\nb", "empty", "", "nil", nil)
+
+	want := `level=info prefix=logfmt msg="Started server" addr=:8080 bad_key="a=b" ` +
+		`quote="say \"hi\"" nl="a\nb" empty="" nil=null` + "\n"
+	if out.String() != want {
+		t.Errorf("got %s, want %s", out.String(), want)
+	}
+}
+
+func TestConcurrentReconfiguration(t *testing.T) {
+	var out bytes.Buffer
+	l := New("race:", -1)
+	l.SetOutput(&out, &out)
+	child := l.With("k", "v")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			l.SetLevel(Level(i % 3))
+			l.SetPrefix("race" + strconv.Itoa(i) + ":")
+			l.SetFlags(i % 2 * log.Lshortfile)
+			l.SetFormat(Format(i % 3))
+			l.SetOutput(&out, &out)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		l.Infoln("parent")
+		child.Errorw("child", "i", i)
+	}
+	<-done
+}
+
+func TestLevelOutput(t *testing.T) {
+	var out, errOut, alerts, debug bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &errOut)
+	l.SetErrThreshold(LevelWarning)
+	l.SetLevelOutput(LevelCritical, &errOut, &alerts)
+	l.SetLevelOutput(LevelTrace, &debug)
+
+	l.Traceln("trace")
+	l.Infoln("info")
+	l.Warningln("warning")
+	l.Criticalln("critical")
+	l.SetLevelOutput(LevelTrace)
+	l.Traceln("trace again")
+
+	for _, c := range []struct {
+		name string
+		got  *bytes.Buffer
+		want string
+	}{
+		{"out", &out, "[INF] info\n[TRC] trace again\n"},
+		{"err", &errOut, "[WRN] warning\n[CRT] critical\n"},
+		{"alerts", &alerts, "[CRT] critical\n"},
+		{"debug", &debug, "[TRC] trace\n"},
+	} {
+		if c.got.String() != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, c.got.String(), c.want)
+		}
+	}
+}
+
+type nilStringer struct{ s string }
+
+func (s *nilStringer) String() string { return s.s }
+
+func TestFormatJSONTypedNil(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetFormat(FormatJSON)
+	var s *nilStringer
+	var err *nilError
+	l.Infow("nil", "s", s, "err", error(err))
+	want := `{"level":"info","msg":"nil","s":"<nil>","err":{"msg":"<nil>","type":"*golog.nilError"}}` + "\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestFormatLogfmtTypedNil(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetFormat(FormatLogfmt)
+	var err *nilError
+	l.Infow("nil", "err", error(err), "s", (*nilStringer)(nil))
+	want := "level=info msg=nil err=<nil> s=<nil>\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,204 @@
+// Package gologtest provides helpers to test code which logs with golog:
+// a recorder which captures entries in memory and assertions on them.
+// Assertions check levels and messages instead of formatted lines,
+// so tests don't break when flags, prefixes or formats change.
+//
+//	r := gologtest.New()
+//	process(r.Logger)
+//	r.AssertLogged(t, golog.LevelError, "connection refused")
+//	r.ExpectSequence(t,
+//		gologtest.Expect{Level: golog.LevelInfo, Message: "started"},
+//		gologtest.Expect{Level: golog.LevelInfo, Message: "stopped"},
+//	)
+package gologtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nordborn/golog"
+)
+
+// Recorder captures entries in memory.
+// It's a golog.Hook, so it can be added to any logger.
+type Recorder struct {
+	// Logger writes all entries to the recorder only,
+	// Fatal methods of the logger don't stop the process.
+	*golog.Logger
+
+	mu       sync.Mutex
+	entries  []golog.Entry
+	exitCode int
+	exited   bool
+}
+
+// New creates new recorder with the logger which
+// records entries of all levels.
+func New() *Recorder {
+	r := &Recorder{Logger: golog.New("", 0)}
+	r.SetLevel(golog.LevelTrace)
+	r.SetOutput(ioutil.Discard, ioutil.Discard)
+	r.SetExitFunc(r.exit)
+	r.AddHook(r)
+	return r
+}
+
+// RecordGlobal makes the global logger write entries to the new recorder
+// instead of its outputs until the end of the test.
+// Fatal functions of the global logger don't stop the process.
+// The global logger's configuration is restored by t.Cleanup().
+func RecordGlobal(t testing.TB) *Recorder {
+	Snapshot(t)
+	r := New()
+	golog.SetOutput(ioutil.Discard, ioutil.Discard)
+	golog.SetExitFunc(r.exit)
+	golog.AddHook(r)
+	return r
+}
+
+// Snapshot saves the global logger's configuration
+// and restores it by t.Cleanup().
+func Snapshot(t testing.TB) {
+	t.Cleanup(golog.Snapshot())
+}
+
+// Levels implements golog.Hook.
+func (r *Recorder) Levels() []golog.Level {
+	return golog.AllLevels
+}
+
+// Fire implements golog.Hook.
+func (r *Recorder) Fire(e *golog.Entry) error {
+	rec := *e
+	// copy to keep the record immutable after other hooks
+	rec.Fields = append([]golog.Field(nil), e.Fields...)
+	r.mu.Lock()
+	r.entries = append(r.entries, rec)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Recorder) exit(code int) {
+	r.mu.Lock()
+	r.exitCode, r.exited = code, true
+	r.mu.Unlock()
+}
+
+// Entries returns a copy of the recorded entries.
+func (r *Recorder) Entries() []golog.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]golog.Entry(nil), r.entries...)
+}
+
+// Exited returns the exit code passed by Fatal methods
+// and reports whether they were called.
+func (r *Recorder) Exited() (code int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.exitCode, r.exited
+}
+
+// Reset removes the recorded entries and the exit status.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+	r.exitCode, r.exited = 0, false
+}
+
+// Expect describes an expected entry:
+// the level and a substring of the message.
+type Expect struct {
+	Level   golog.Level
+	Message string
+}
+
+func (x Expect) match(e golog.Entry) bool {
+	return e.Level == x.Level && strings.Contains(e.Message, x.Message)
+}
+
+func (x Expect) String() string {
+	return fmt.Sprintf("%s %q", x.Level, x.Message)
+}
+
+// AssertLogged reports an error if no entry of the level
+// contains substr in the message.
+func (r *Recorder) AssertLogged(t testing.TB, level golog.Level, substr string) bool {
+	t.Helper()
+	x := Expect{Level: level, Message: substr}
+	entries := r.Entries()
+	for _, e := range entries {
+		if x.match(e) {
+			return true
+		}
+	}
+	t.Errorf("gologtest: no entry %s, recorded:\n%s", x, formatEntries(entries))
+	return false
+}
+
+// AssertNotLogged reports an error if an entry of the level
+// contains substr in the message.
+func (r *Recorder) AssertNotLogged(t testing.TB, level golog.Level, substr string) bool {
+	t.Helper()
+	x := Expect{Level: level, Message: substr}
+	for _, e := range r.Entries() {
+		if x.match(e) {
+			t.Errorf("gologtest: unexpected entry %s", formatEntry(e))
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectSequence reports an error if the recorded entries don't contain
+// the expected ones in the given order.
+// Other entries may appear between the expected ones.
+func (r *Recorder) ExpectSequence(t testing.TB, seq ...Expect) bool {
+	t.Helper()
+	entries := r.Entries()
+	i := 0
+	for _, e := range entries {
+		if i < len(seq) && seq[i].match(e) {
+			i++
+		}
+	}
+	if i == len(seq) {
+		return true
+	}
+	t.Errorf("gologtest: no entry %s after %d matched of %d expected, recorded:\n%s",
+		seq[i], i, len(seq), formatEntries(entries))
+	return false
+}
+
+func formatEntries(entries []golog.Entry) string {
+	if len(entries) == 0 {
+		return "\t(none)"
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = "\t" + formatEntry(e)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatEntry returns e as "LEVEL prefix file:line: message k=v".
+func formatEntry(e golog.Entry) string {
+	var b strings.Builder
+	b.WriteString(e.Level.String())
+	if e.Prefix != "" {
+		b.WriteString(" " + e.Prefix)
+	}
+	if e.Caller.File != "" {
+		i := strings.LastIndexByte(e.Caller.File, '/')
+		fmt.Fprintf(&b, " %s:%d:", e.Caller.File[i+1:], e.Caller.Line)
+	}
+	fmt.Fprintf(&b, " %q", e.Message)
+	for _, f := range e.Fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	return b.String()
+}
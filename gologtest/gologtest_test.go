@@ -0,0 +1,78 @@
+package gologtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/nordborn/golog"
+)
+
+// fakeT records errors instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	r := New()
+	l := r.With("id", 7)
+	l.Info("started")
+	l.Errorf("query failed: %s", "timeout")
+	l.Fatal("giving up")
+
+	entries := r.Entries()
+	if len(entries) != 3 || entries[1].Fields[0] != (golog.Field{Key: "id", Value: 7}) ||
+		!strings.HasSuffix(entries[1].Caller.File, "gologtest_test.go") {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if code, ok := r.Exited(); !ok || code != 1 {
+		t.Errorf("exited with %d, %v", code, ok)
+	}
+
+	r.AssertLogged(t, golog.LevelError, "timeout")
+	r.AssertNotLogged(t, golog.LevelWarning, "timeout")
+	r.ExpectSequence(t,
+		Expect{Level: golog.LevelInfo, Message: "started"},
+		Expect{Level: golog.LevelFatal, Message: "giving up"},
+	)
+
+	ft := &fakeT{TB: t}
+	r.AssertLogged(ft, golog.LevelInfo, "timeout")
+	r.ExpectSequence(ft,
+		Expect{Level: golog.LevelFatal, Message: "giving up"},
+		Expect{Level: golog.LevelInfo, Message: "started"},
+	)
+	if len(ft.errors) != 2 || !strings.Contains(ft.errors[1], `no entry info "started" after 1 matched of 2`) {
+		t.Errorf("unexpected errors: %q", ft.errors)
+	}
+
+	r.Reset()
+	if len(r.Entries()) != 0 {
+		t.Error("entries are not reset")
+	}
+}
+
+func TestRecordGlobal(t *testing.T) {
+	t.Run("record", func(t *testing.T) {
+		r := RecordGlobal(t)
+		golog.Info("recorded")
+		golog.SetLevel(golog.LevelOff)
+		golog.Info("hidden")
+		r.AssertLogged(t, golog.LevelInfo, "recorded")
+		r.AssertNotLogged(t, golog.LevelInfo, "hidden")
+	})
+	defer golog.Snapshot()()
+	r := New()
+	golog.AddHook(r)
+	golog.SetOutput(ioutil.Discard, ioutil.Discard)
+	golog.Info("restored")
+	r.AssertLogged(t, golog.LevelInfo, "restored")
+}
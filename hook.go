@@ -0,0 +1,54 @@
+package golog
+
+import "fmt"
+
+// AllLevels contains all levels of messages, it's handy
+// for hooks which should be fired for every entry.
+var AllLevels = []Level{
+	LevelTrace,
+	LevelDebug,
+	LevelInfo,
+	LevelWarning,
+	LevelError,
+	LevelCritical,
+	LevelPanic,
+	LevelFatal,
+}
+
+// Hook is fired for every emitted entry of the levels
+// returned by Levels() before the entry is written.
+// Hooks can modify the entry (e.g. add fields), count it
+// or forward it to other systems.
+// Fire() can be called from multiple goroutines simultaneously.
+type Hook interface {
+	Levels() []Level
+	Fire(*Entry) error
+}
+
+// AddHook adds the hook to the logger.
+// Hooks are fired in order of addition,
+// errors of hooks are reported to ErrDefault.
+func (l *Logger) AddHook(h Hook) {
+	l.update(func(c *config) {
+		c.hasHooks = true
+		for _, lvl := range h.Levels() {
+			if lvl < LevelTrace || lvl >= LevelOff {
+				continue
+			}
+			// copy to keep previous snapshots immutable
+			c.hooks[lvl] = append(c.hooks[lvl][:len(c.hooks[lvl]):len(c.hooks[lvl])], h)
+		}
+	})
+}
+
+// fireHooks fires the hooks of c for e.
+func (c *config) fireHooks(e *Entry) {
+	if e.Level < LevelTrace || e.Level >= LevelOff {
+		return
+	}
+	for _, h := range c.hooks[e.Level] {
+		if err := h.Fire(e); err != nil {
+			fmt.Fprintf(ErrDefault, "golog: failed to fire hook: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type testHook struct {
+	levels  []Level
+	entries []Entry
+}
+
+func (h *testHook) Levels() []Level {
+	return h.levels
+}
+
+func (h *testHook) Fire(e *Entry) error {
+	h.entries = append(h.entries, *e)
+	e.Fields = append(e.Fields, Field{Key: "host", Value: "web-1"})
+	return nil
+}
+
+func TestHook(t *testing.T) {
+	var out bytes.Buffer
+	l := New("hook:", 0)
+	l.SetOutput(&out, &out)
+	h := &testHook{levels: []Level{LevelError, LevelCritical}}
+	l.AddHook(h)
+
+	l.Infoln("not fired")
+	l.Errorw("Failed", "code", 7)
+
+	if len(h.entries) != 1 {
+		t.Fatalf("fired %d times", len(h.entries))
+	}
+	e := h.entries[0]
+	if e.Level != LevelError || e.Prefix != "hook:" || e.Message != "Failed" ||
+		len(e.Fields) != 1 || e.Fields[0] != (Field{Key: "code", Value: 7}) ||
+		!strings.HasSuffix(e.Caller.File, "hook_test.go") || e.Time.IsZero() {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	want := "[INF] hook: not fired\n[ERR] hook: Failed code=7 host=web-1\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
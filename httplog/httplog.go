@@ -0,0 +1,283 @@
+// Package httplog provides net/http middleware which logs requests
+// with golog and recovers panics of handlers.
+//
+// Common entry using `httplog.New(nil, golog.LevelInfo).Handler(mux)` will be:
+// [INF] main: 2018/11/26 16:57:49 httplog.go:110: HTTP request method=GET path=/api status=200 bytes=12 duration=1.2ms remote_addr=127.0.0.1:50420 user_agent=curl/7.58.0
+package httplog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nordborn/golog"
+)
+
+// clfTimeFormat is the time format of Apache Common/Combined Log Format.
+const clfTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// Middleware logs method, path, status, bytes, duration,
+// remote address and user agent of every request:
+// 5xx responses with golog.LevelError, 4xx with golog.LevelWarning,
+// others with the configured level.
+// Panics of handlers are recovered and logged with golog.LevelCritical,
+// the value and the stack of the panic are in the "panic" field,
+// the client gets 500 Internal Server Error.
+type Middleware struct {
+	logger *golog.Logger
+	level  golog.Level
+
+	mu       sync.Mutex // serializes writes to combined
+	combined io.Writer
+}
+
+// New creates new middleware which logs requests with l at the level.
+// Use l==nil to log with the logger from the request context
+// (see golog.FromContext()) which is the global logger by default.
+func New(l *golog.Logger, level golog.Level) *Middleware {
+	return &Middleware{logger: l, level: level}
+}
+
+// SetCombinedLog makes the middleware write access lines to w
+// in Apache Combined Log Format instead of golog's layout.
+// Panics are still logged with golog.
+// Use w==nil to log with golog again.
+func (m *Middleware) SetCombinedLog(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.combined = w
+}
+
+// Handler wraps next with logging and panic recovery.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					// the server aborts the response silently
+					panic(v)
+				}
+				m.loggerFor(r).Criticalw("panic serving "+r.Method+" "+r.URL.Path, "panic", newPanicError(v))
+				if !rw.wroteHeader {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				} else {
+					// the client mustn't get the truncated response as successful
+					rw.status = http.StatusInternalServerError
+				}
+			}
+			m.log(r, rw, start)
+		}()
+		next.ServeHTTP(rw.wrap(), r)
+	})
+}
+
+func (m *Middleware) loggerFor(r *http.Request) *golog.Logger {
+	if m.logger != nil {
+		return m.logger
+	}
+	return golog.FromContext(r.Context())
+}
+
+// log writes the access entry of the request.
+func (m *Middleware) log(r *http.Request, rw *responseWriter, start time.Time) {
+	status := rw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	m.mu.Lock()
+	combined := m.combined
+	if combined != nil {
+		combined.Write(appendCombined(nil, r, status, rw.bytes, start))
+	}
+	m.mu.Unlock()
+	if combined != nil {
+		return
+	}
+	level := m.level
+	switch {
+	case status >= 500:
+		level = golog.LevelError
+	case status >= 400:
+		level = golog.LevelWarning
+	}
+	m.loggerFor(r).Logw(level, "HTTP request",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", status,
+		"bytes", rw.bytes,
+		"duration", time.Since(start),
+		"remote_addr", r.RemoteAddr,
+		"user_agent", r.UserAgent(),
+	)
+}
+
+// appendCombined appends the request line in Apache Combined Log Format:
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326 "http://x/" "Mozilla/4.08"
+func appendCombined(buf []byte, r *http.Request, status int, bytes int64, start time.Time) []byte {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user := "-"
+	if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	} else if name, _, ok := r.BasicAuth(); ok && name != "" {
+		user = name
+	}
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	buf = append(buf, host...)
+	buf = append(buf, " - "...)
+	buf = append(buf, user...)
+	buf = append(buf, " ["...)
+	buf = start.AppendFormat(buf, clfTimeFormat)
+	buf = append(buf, "] "...)
+	buf = strconv.AppendQuote(buf, r.Method+" "+uri+" "+r.Proto)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(status), 10)
+	buf = append(buf, ' ')
+	if bytes == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = strconv.AppendInt(buf, bytes, 10)
+	}
+	buf = append(buf, ' ')
+	buf = strconv.AppendQuote(buf, r.Referer())
+	buf = append(buf, ' ')
+	buf = strconv.AppendQuote(buf, r.UserAgent())
+	return append(buf, '\n')
+}
+
+// panicError is the recovered value with the stack of the panic,
+// golog renders the stack after the entry as of other errors with stacks.
+type panicError struct {
+	value interface{}
+	pcs   []uintptr
+}
+
+// newPanicError returns panicError of v,
+// it must be called from the deferred function which recovered.
+func newPanicError(v interface{}) panicError {
+	var pcs [64]uintptr
+	n := runtime.Callers(3, pcs[:])
+	for i, pc := range pcs[:n] {
+		// the stack of the panic starts after runtime.gopanic
+		if f := runtime.FuncForPC(pc - 1); f != nil && f.Name() == "runtime.gopanic" {
+			return panicError{value: v, pcs: pcs[i+1 : n]}
+		}
+	}
+	return panicError{value: v, pcs: pcs[:n]}
+}
+
+func (e panicError) Error() string {
+	return fmt.Sprint(e.value)
+}
+
+// StackTrace returns program counters of the stack of the panic.
+func (e panicError) StackTrace() []uintptr {
+	return e.pcs
+}
+
+// responseWriter records the status and the size of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// wrap returns w implementing http.Flusher and http.Hijacker
+// only if the underlying writer implements them,
+// so handlers can detect unsupported features (e.g. with HTTP/2).
+func (w *responseWriter) wrap() http.ResponseWriter {
+	_, canFlush := w.ResponseWriter.(http.Flusher)
+	_, canHijack := w.ResponseWriter.(http.Hijacker)
+	switch {
+	case canFlush && canHijack:
+		return flushHijacker{w}
+	case canFlush:
+		return flusher{w}
+	case canHijack:
+		return hijacker{w}
+	}
+	return w
+}
+
+func (w *responseWriter) flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *responseWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !w.wroteHeader {
+		// hijacked connections are logged as 101 Switching Protocols
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// flusher is responseWriter of http.Flusher.
+type flusher struct {
+	*responseWriter
+}
+
+func (w flusher) Flush() {
+	w.flush()
+}
+
+// hijacker is responseWriter of http.Hijacker.
+type hijacker struct {
+	*responseWriter
+}
+
+func (w hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// flushHijacker is responseWriter of http.Flusher and http.Hijacker.
+type flushHijacker struct {
+	*responseWriter
+}
+
+func (w flushHijacker) Flush() {
+	w.flush()
+}
+
+func (w flushHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
@@ -0,0 +1,112 @@
+package httplog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nordborn/golog"
+)
+
+func TestMiddleware(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := golog.New("http:", 0)
+	l.SetOutput(&out, &errOut)
+	l.SetFormat(golog.FormatLogfmt)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	h := New(l, golog.LevelInfo).Handler(mux)
+
+	for _, path := range []string{"/ok", "/missing", "/panic"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("User-Agent", "test agent")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	re := regexp.MustCompile(`duration=\S+ `)
+	got := re.ReplaceAllString(out.String(), "")
+	want := "level=info prefix=http msg=\"HTTP request\" method=GET path=/ok status=200 bytes=5 " +
+		"remote_addr=192.0.2.1:1234 user_agent=\"test agent\"\n" +
+		"level=warning prefix=http msg=\"HTTP request\" method=GET path=/missing status=404 bytes=19 " +
+		"remote_addr=192.0.2.1:1234 user_agent=\"test agent\"\n"
+	if got != want {
+		t.Errorf("out: got %s, want %s", got, want)
+	}
+	lines := strings.SplitN(errOut.String(), "\n", 2)
+	if lines[0] != `level=critical prefix=http msg="panic serving GET /panic" panic=boom` {
+		t.Errorf("panic entry: %s", lines[0])
+	}
+	if !strings.Contains(errOut.String(), "status=500") {
+		t.Errorf("no access entry for the panic: %s", errOut.String())
+	}
+}
+
+func TestCombinedLog(t *testing.T) {
+	var combined bytes.Buffer
+	m := New(golog.New("", 0), golog.LevelInfo)
+	m.SetCombinedLog(&combined)
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+	req := httptest.NewRequest("POST", "/items?id=1", nil)
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "http://example.com/")
+	req.Header.Set("User-Agent", "Mozilla/4.08")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	re := regexp.MustCompile(`^192\.0\.2\.1 - frank \[[^\]]+\] "POST /items\?id=1 HTTP/1\.1" 201 7 "http://example\.com/" "Mozilla/4\.08"\n$`)
+	if !re.MatchString(combined.String()) {
+		t.Errorf("got %q", combined.String())
+	}
+}
+
+func TestMiddlewarePanicStack(t *testing.T) {
+	var out bytes.Buffer
+	l := golog.New("", 0)
+	l.SetOutput(&out, &out)
+	h := New(l, golog.LevelInfo).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 3 || lines[0] != "[CRT] panic serving GET /panic panic=boom" ||
+		!strings.HasPrefix(lines[1], "\t\tat github.com/nordborn/golog/httplog.TestMiddlewarePanicStack.func1 ") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+// plainWriter implements http.ResponseWriter only.
+type plainWriter struct {
+	http.ResponseWriter
+}
+
+func TestMiddlewareInterfaces(t *testing.T) {
+	m := New(golog.New("", 0), golog.LevelInfo)
+	m.SetCombinedLog(&bytes.Buffer{})
+	var flusher, hijacker bool
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+
+	// httptest.ResponseRecorder implements http.Flusher only
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if !flusher || hijacker {
+		t.Errorf("recorder: flusher %v, hijacker %v", flusher, hijacker)
+	}
+	h.ServeHTTP(plainWriter{httptest.NewRecorder()}, req)
+	if flusher || hijacker {
+		t.Errorf("plain writer: flusher %v, hijacker %v", flusher, hijacker)
+	}
+}
@@ -0,0 +1,276 @@
+//go:build linux
+
+package golog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// JournaldSocket is the socket of the native journal protocol.
+const JournaldSocket = "/run/systemd/journal/socket"
+
+// journaldMaxField is the max length of journal field names.
+const journaldMaxField = 64
+
+// memfd_create(2) and fcntl(2) constants.
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fAddSeals       = 1033
+	fSealAll        = 0x1 | 0x2 | 0x4 | 0x8 // seal, shrink, grow, write
+)
+
+// JournaldHook is a hook which sends entries to systemd-journald
+// using the native journal protocol, so severities and fields
+// are kept: PRIORITY (as syslog severity), MESSAGE, CODE_FILE,
+// CODE_LINE, CODE_FUNC, SYSLOG_IDENTIFIER (the custom prefix
+// or the program's name), STACK and fields of the entry with keys
+// converted to journal field names: "user_id" -> "USER_ID".
+// Entries too large for a datagram are passed as sealed memfd
+// or as unlinked temporary files.
+// The hook reconnects as SyslogHook, e.g. after restarts of journald.
+type JournaldHook struct {
+	addr    *net.UnixAddr
+	ident   string
+	dropped uint64 // atomic
+	mu      sync.Mutex
+	conn    *net.UnixConn // nil after a failed reconnection
+	closed  bool
+	retry   backoff
+}
+
+// NewJournaldHook connects to journald at the socket path
+// (JournaldSocket if empty) and returns the hook which sends
+// entries of all levels, add it with AddHook().
+func NewJournaldHook(path string) (*JournaldHook, error) {
+	if path == "" {
+		path = JournaldSocket
+	}
+	h := &JournaldHook{
+		addr:  &net.UnixAddr{Name: path, Net: "unixgram"},
+		ident: filepath.Base(os.Args[0]),
+		retry: backoff{min: reconnectMinBackoff, max: reconnectMaxBackoff},
+	}
+	if err := h.connect(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// connect dials journald, h.mu must be held.
+func (h *JournaldHook) connect() error {
+	conn, err := net.DialUnix("unixgram", nil, h.addr)
+	if err != nil {
+		return fmt.Errorf("golog: failed to connect to journald: %v", err)
+	}
+	h.conn = conn
+	return nil
+}
+
+// Levels implements Hook.
+func (h *JournaldHook) Levels() []Level {
+	return AllLevels
+}
+
+// Fire implements Hook.
+func (h *JournaldHook) Fire(e *Entry) error {
+	msg := h.format(nil, e)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	if h.conn != nil {
+		err := h.send(msg)
+		if err == nil || isMsgTooLarge(err) {
+			return err
+		}
+		// journald could be restarted
+		h.conn.Close()
+		h.conn = nil
+	}
+	if h.retry.wait() {
+		atomic.AddUint64(&h.dropped, 1)
+		return nil
+	}
+	if err := h.connect(); err != nil {
+		h.retry.failed()
+		atomic.AddUint64(&h.dropped, 1)
+		return err
+	}
+	h.retry.reset()
+	return h.send(msg)
+}
+
+// Dropped returns the number of entries dropped
+// because journald was unavailable.
+func (h *JournaldHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// send sends msg as a datagram or via a file
+// if it's too large, h.mu must be held.
+func (h *JournaldHook) send(msg []byte) error {
+	_, err := h.conn.Write(msg)
+	if !isMsgTooLarge(err) {
+		return err
+	}
+	f, err := journaldFile(msg)
+	if err != nil {
+		return fmt.Errorf("golog: failed to send large entry to journald: %v", err)
+	}
+	defer f.Close()
+	// net doesn't allow WriteMsgUnix() on connected datagram sockets
+	rc, err := h.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	oob := syscall.UnixRights(int(f.Fd()))
+	werr := rc.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, oob, nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if werr != nil {
+		return werr
+	}
+	return err
+}
+
+// Close closes the connection, entries fired after Close are not sent.
+func (h *JournaldHook) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+	if h.conn == nil {
+		return nil
+	}
+	return h.conn.Close()
+}
+
+// format appends e to buf in the native journal protocol.
+func (h *JournaldHook) format(buf []byte, e *Entry) []byte {
+	buf = appendJournaldField(buf, "PRIORITY", strconv.Itoa(syslogSeverity(e.Level)))
+	buf = appendJournaldField(buf, "MESSAGE", e.Message)
+	ident := prefixName(e.Prefix)
+	if ident == "" {
+		ident = h.ident
+	}
+	buf = appendJournaldField(buf, "SYSLOG_IDENTIFIER", ident)
+	if e.Caller.File != "" {
+		buf = appendJournaldField(buf, "CODE_FILE", e.Caller.File)
+		buf = appendJournaldField(buf, "CODE_LINE", strconv.Itoa(e.Caller.Line))
+		buf = appendJournaldField(buf, "CODE_FUNC", e.Caller.Function)
+	}
+	if len(e.Stack) > 0 {
+		stack := appendStackText(nil, e.Stack)
+		buf = appendJournaldField(buf, "STACK", string(stack[1:])) // without leading '\n'
+	}
+	for _, f := range e.Fields {
+		buf = appendJournaldField(buf, journaldKey(f.Key), fmt.Sprint(f.Value))
+	}
+	return buf
+}
+
+// appendJournaldField appends the field to buf as "KEY=value\n"
+// or, if the value contains newlines, as "KEY\n", the value's size
+// as 64-bit little endian integer, the value and "\n".
+func appendJournaldField(buf []byte, key, value string) []byte {
+	buf = append(buf, key...)
+	if strings.IndexByte(value, '\n') < 0 {
+		buf = append(buf, '=')
+	} else {
+		var size [8]byte
+		binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+		buf = append(buf, '\n')
+		buf = append(buf, size[:]...)
+	}
+	buf = append(buf, value...)
+	return append(buf, '\n')
+}
+
+// journaldKey converts k to the journal field name: uppercase ASCII
+// letters, digits and underscores, starting with a letter.
+func journaldKey(k string) string {
+	b := make([]byte, 0, len(k))
+	for i := 0; i < len(k) && len(b) < journaldMaxField; i++ {
+		c := k[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9' && len(b) > 0:
+		case len(b) > 0:
+			c = '_'
+		default:
+			// leading digits and underscores are dropped:
+			// fields starting with '_' are trusted fields of journald
+			continue
+		}
+		b = append(b, c)
+	}
+	if len(b) == 0 {
+		return "FIELD"
+	}
+	return string(b)
+}
+
+// isMsgTooLarge reports whether err means that
+// the datagram is too large for the socket.
+func isMsgTooLarge(err error) bool {
+	return errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS)
+}
+
+// journaldFile returns a file with data: sealed memfd
+// if it's supported or an unlinked temporary file in /dev/shm.
+func journaldFile(data []byte) (*os.File, error) {
+	if f, err := memfdCreate("golog-journal"); err == nil {
+		if _, err = f.Write(data); err == nil {
+			if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), fAddSeals, fSealAll); errno == 0 {
+				return f, nil
+			}
+		}
+		f.Close()
+	}
+	f, err := ioutil.TempFile("/dev/shm", "golog-journal-")
+	if err != nil {
+		if f, err = ioutil.TempFile("", "golog-journal-"); err != nil {
+			return nil, err
+		}
+	}
+	os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// memfdCreate creates memfd which allows sealing.
+func memfdCreate(name string) (*os.File, error) {
+	if sysMemfdCreate == 0 {
+		return nil, syscall.ENOSYS
+	}
+	p, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, errno := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(p)), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	return os.NewFile(fd, name), nil
+}
@@ -0,0 +1,5 @@
+package golog
+
+// sysMemfdCreate is the number of memfd_create(2),
+// the syscall package doesn't define it for amd64.
+const sysMemfdCreate = 319
@@ -0,0 +1,6 @@
+package golog
+
+import "syscall"
+
+// sysMemfdCreate is the number of memfd_create(2).
+const sysMemfdCreate = syscall.SYS_MEMFD_CREATE
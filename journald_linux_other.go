@@ -0,0 +1,7 @@
+//go:build linux && !amd64 && !arm64
+
+package golog
+
+// sysMemfdCreate is 0 if memfd isn't used:
+// large journal entries are passed as temporary files.
+const sysMemfdCreate = 0
@@ -0,0 +1,154 @@
+//go:build linux
+
+package golog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// readJournal reads an entry of the native journal protocol from pc.
+func readJournal(t *testing.T, pc *net.UnixConn) map[string]string {
+	buf := make([]byte, 1<<16)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, oobn, _, _, err := pc.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := buf[:n]
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fds, err := syscall.ParseUnixRights(&msgs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := os.NewFile(uintptr(fds[0]), "journal")
+		defer f.Close()
+		if _, err = f.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		if data, err = ioutil.ReadAll(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "=\n")
+		key := string(data[:i])
+		if data[i] == '=' {
+			j := bytes.IndexByte(data, '\n')
+			fields[key] = string(data[i+1 : j])
+			data = data[j+1:]
+			continue
+		}
+		size := int(binary.LittleEndian.Uint64(data[i+1:]))
+		fields[key] = string(data[i+9 : i+9+size])
+		data = data[i+10+size:]
+	}
+	return fields
+}
+
+func TestJournald(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.sock")
+	pc, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	h, err := NewJournaldHook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := New("billing:", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+
+	l.Warningw("slow query", "query_ms", 1500, "sql", "SELECT 1\nFROM t", "_PID", 1)
+	got := readJournal(t, pc)
+	want := map[string]string{
+		"PRIORITY":          "4",
+		"MESSAGE":           "slow query",
+		"SYSLOG_IDENTIFIER": "billing",
+		"CODE_FUNC":         "github.com/nordborn/golog.TestJournald",
+		"QUERY_MS":          "1500",
+		"SQL":               "SELECT 1\nFROM t",
+		"PID":               "1",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s=%q, want %q", k, got[k], v)
+		}
+	}
+	if !strings.HasSuffix(got["CODE_FILE"], "journald_test.go") || got["CODE_LINE"] == "" {
+		t.Errorf("unexpected caller: %q:%q", got["CODE_FILE"], got["CODE_LINE"])
+	}
+
+	large := strings.Repeat("x", 1<<20)
+	l.Error(large)
+	if got = readJournal(t, pc); got["MESSAGE"] != large || got["PRIORITY"] != "3" {
+		t.Errorf("unexpected large entry: %d bytes", len(got["MESSAGE"]))
+	}
+}
+
+func TestJournaldReconnect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal.sock")
+	listen := func() *net.UnixConn {
+		pc, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pc
+	}
+	pc := listen()
+	h, err := NewJournaldHook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	// journald is restarting: the socket doesn't exist for a while
+	pc.Close()
+	os.Remove(path)
+	if err := h.Fire(&Entry{Message: "lost"}); err == nil {
+		t.Fatal("entry is sent without journald")
+	}
+	pc = listen()
+	defer pc.Close()
+	// the next attempt is delayed
+	if err := h.Fire(&Entry{Message: "dropped"}); err != nil || h.Dropped() != 2 {
+		t.Errorf("%v, %d dropped", err, h.Dropped())
+	}
+	h.mu.Lock()
+	h.retry.retryAt = time.Now()
+	h.mu.Unlock()
+	if err := h.Fire(&Entry{Message: "sent"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readJournal(t, pc); got["MESSAGE"] != "sent" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package golog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+const hex = "0123456789abcdef"
+
+// appendJSON appends e to buf as a JSON object followed by a newline.
+// Keys order: level, time, prefix, caller, msg, fields, stack.
+func appendJSON(buf []byte, e *Entry, flags int) []byte {
+	buf = append(buf, `{"level":`...)
+	buf = appendJSONString(buf, e.Level.String())
+	if flags&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
+		buf = append(buf, `,"time":"`...)
+		buf = appendTime(buf, e, flags)
+		buf = append(buf, '"')
+	}
+	if p := prefixName(e.Prefix); p != "" {
+		buf = append(buf, `,"prefix":`...)
+		buf = appendJSONString(buf, p)
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		buf = append(buf, `,"caller":"`...)
+		buf = appendJSONStringContent(buf, string(appendCaller(nil, e, flags)))
+		buf = append(buf, '"')
+	}
+	buf = append(buf, `,"msg":`...)
+	buf = appendJSONString(buf, e.Message)
+	for _, f := range e.Fields {
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, f.Key)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, f.Value)
+	}
+	if len(e.Stack) > 0 {
+		buf = append(buf, `,"stack":[`...)
+		for i, s := range stackStrings(e.Stack) {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, s)
+		}
+		buf = append(buf, ']')
+	}
+	return append(buf, "}\n"...)
+}
+
+// appendJSONValue appends v to buf as a JSON value.
+// Errors are rendered as objects with causal chains (see errorNode),
+// fmt.Stringers are rendered as strings,
+// other types are marshaled with encoding/json
+// (or rendered with fmt.Sprint if they can't be marshaled).
+func appendJSONValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		return appendJSONFloat(buf, float64(v), 32)
+	case float64:
+		return appendJSONFloat(buf, v, 64)
+	case error:
+		n := newErrorNode(v, 0)
+		return n.appendJSON(buf)
+	case json.Marshaler:
+		// checked before fmt.Stringer to keep e.g. time.Time in RFC3339
+	case fmt.Stringer:
+		return appendJSONString(buf, stringerString(v))
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprint(v))
+	}
+	return append(buf, b...)
+}
+
+// appendJSONFloat appends f to buf, NaN and infinities are quoted
+// because JSON doesn't support them.
+func appendJSONFloat(buf []byte, f float64, bitSize int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, bitSize))
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize)
+}
+
+// appendJSONString appends s to buf as a quoted JSON string.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	buf = appendJSONStringContent(buf, s)
+	return append(buf, '"')
+}
+
+// appendJSONStringContent appends escaped s to buf without quotes.
+// Invalid UTF-8 is replaced with U+FFFD,
+// U+2028 and U+2029 are escaped as in encoding/json.
+func appendJSONStringContent(buf []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	return append(buf, s[start:]...)
+}
@@ -1,6 +1,15 @@
 package golog
 
-type levelType int
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is a logging level.
+// It implements encoding.TextMarshaler, encoding.TextUnmarshaler
+// and flag.Value, so it can be used directly in JSON/YAML configs
+// and command line flags.
+type Level int
 
 // Levels hierarchy:
 // - trace;
@@ -8,18 +17,117 @@ type levelType int
 // - info;
 // - warning;
 // - error;
-// - critical.
+// - critical;
+// - panic;
+// - fatal.
 // Use in SetLevel():
 // LevelTrace - to display all messages;
 // LevelDebug - to display debug messages and above;
 // LevelInfo - to display info messages and above;
 // LevelWarning - to display warning messages and above;
-// LevelError - to display error messages and above.
+// LevelError - to display error messages and above;
+// LevelCritical - to display critical messages and above;
+// LevelPanic - to display panic and fatal messages;
+// LevelFatal - to display fatal messages only;
+// LevelOff - to suppress all messages.
 // Default level: LevelTrace
 const (
-	LevelTrace levelType = iota
+	LevelTrace Level = iota
 	LevelDebug
 	LevelInfo
 	LevelWarning
 	LevelError
+	LevelCritical
+	LevelPanic
+	LevelFatal
+	LevelOff
 )
+
+var levelNames = [...]string{
+	LevelTrace:    "trace",
+	LevelDebug:    "debug",
+	LevelInfo:     "info",
+	LevelWarning:  "warning",
+	LevelError:    "error",
+	LevelCritical: "critical",
+	LevelPanic:    "panic",
+	LevelFatal:    "fatal",
+	LevelOff:      "off",
+}
+
+// String returns the level name: "trace", "debug", "info" etc.
+func (lvl Level) String() string {
+	if lvl >= 0 && int(lvl) < len(levelNames) {
+		return levelNames[lvl]
+	}
+	return fmt.Sprintf("Level(%d)", int(lvl))
+}
+
+// ParseLevel parses the level name (case-insensitive).
+// Besides the names returned by String(), it accepts
+// "warn", "err", "crit" and "none".
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	switch name {
+	case "warn":
+		return LevelWarning, nil
+	case "err":
+		return LevelError, nil
+	case "crit":
+		return LevelCritical, nil
+	case "none":
+		return LevelOff, nil
+	}
+	for lvl, n := range levelNames {
+		if n == name {
+			return Level(lvl), nil
+		}
+	}
+	return LevelTrace, fmt.Errorf("golog: unknown level %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (lvl Level) MarshalText() ([]byte, error) {
+	if lvl < 0 || int(lvl) >= len(levelNames) {
+		return nil, fmt.Errorf("golog: invalid level %d", int(lvl))
+	}
+	return []byte(levelNames[lvl]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (lvl *Level) UnmarshalText(text []byte) error {
+	v, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lvl = v
+	return nil
+}
+
+// Set implements flag.Value.
+func (lvl *Level) Set(s string) error {
+	return lvl.UnmarshalText([]byte(s))
+}
+
+// prefix returns the level prefix used in text format.
+func (lvl Level) prefix() string {
+	switch lvl {
+	case LevelTrace:
+		return PrefixTrace
+	case LevelDebug:
+		return PrefixDebug
+	case LevelInfo:
+		return PrefixInfo
+	case LevelWarning:
+		return PrefixWarning
+	case LevelError:
+		return PrefixError
+	case LevelCritical:
+		return PrefixCritical
+	case LevelPanic:
+		return PrefixPanic
+	case LevelFatal:
+		return PrefixFatal
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for lvl := LevelTrace; lvl <= LevelOff; lvl++ {
+		got, err := ParseLevel(lvl.String())
+		if err != nil || got != lvl {
+			t.Errorf("ParseLevel(%q) = %v, %v", lvl.String(), got, err)
+		}
+	}
+	if got, err := ParseLevel(" WARN "); err != nil || got != LevelWarning {
+		t.Errorf("ParseLevel(WARN) = %v, %v", got, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(verbose): expected error")
+	}
+	if s := Level(42).String(); s != "Level(42)" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+	var cfg struct {
+		Level Level `json:"level"`
+	}
+	if err := json.Unmarshal([]byte(`{"level":"critical"}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != LevelCritical {
+		t.Errorf("got %v, want critical", cfg.Level)
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil || string(b) != `{"level":"critical"}` {
+		t.Errorf("Marshal = %s, %v", b, err)
+	}
+	if err := json.Unmarshal([]byte(`{"level":"loud"}`), &cfg); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestLevelThresholds(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetLevel(LevelCritical)
+	l.Errorln("hidden")
+	l.Criticalln("shown")
+	l.SetLevel(LevelOff)
+	l.Criticalln("hidden")
+	if want := "[CRT] shown\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,68 @@
+package golog
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// appendLogfmt appends e to buf as a logfmt line:
+// level=info ts=2018-11-26T16:57:49+03:00 prefix=main caller=main.go:61 msg=Started
+// Keys order: level, ts, prefix, caller, msg, fields, stack.
+func appendLogfmt(buf []byte, e *Entry, flags int) []byte {
+	buf = append(buf, "level="...)
+	buf = append(buf, e.Level.String()...)
+	if flags&(log.Ldate|log.Ltime|log.Lmicroseconds) != 0 {
+		buf = append(buf, " ts="...)
+		buf = appendTime(buf, e, flags)
+	}
+	if p := prefixName(e.Prefix); p != "" {
+		buf = append(buf, " prefix="...)
+		buf = append(buf, quoteIfNeeded(p)...)
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 {
+		buf = append(buf, " caller="...)
+		buf = append(buf, quoteIfNeeded(string(appendCaller(nil, e, flags)))...)
+	}
+	buf = append(buf, " msg="...)
+	buf = append(buf, quoteIfNeeded(e.Message)...)
+	for _, f := range e.Fields {
+		buf = append(buf, ' ')
+		buf = append(buf, logfmtKey(f.Key)...)
+		buf = append(buf, '=')
+		buf = append(buf, quoteIfNeeded(logfmtValue(f.Value))...)
+	}
+	if len(e.Stack) > 0 {
+		// logfmt has no arrays, frames are separated with newlines
+		buf = append(buf, " stack="...)
+		buf = append(buf, quoteIfNeeded(strings.Join(stackStrings(e.Stack), "\n"))...)
+	}
+	return append(buf, '\n')
+}
+
+// logfmtKey replaces characters which aren't allowed in logfmt keys
+// (spaces, '=', quotes and non-printable characters) with '_'.
+func logfmtKey(k string) string {
+	if k == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			return '_'
+		}
+		return r
+	}, k)
+}
+
+// logfmtValue renders v as a string, nil is rendered as null.
+func logfmtValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	}
+	// fmt recovers panics of Error() and String(), e.g. for typed nils
+	return fmt.Sprint(v)
+}
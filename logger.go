@@ -1,82 +1,46 @@
 package golog
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
 	"os"
 	"strings"
+	"time"
 )
 
 // A Logger represents an active logging object that generates lines of
 // output to an io.Writer. Each logging operation makes a single call to
 // the Writer's Write method. A Logger can be used simultaneously from
 // multiple goroutines; it guarantees to serialize access to the Writer.
+// All setters are safe to call concurrently with logging calls:
+// the configuration is replaced atomically.
 // The Logger can prints messages with different levels
 // If you don't know what level to use, just use Info() and Error().
 // Also, it prints to different output file-like objects:
-// - outWriter for levels trace-info (os.Stdout by default);
-// - errWriter for levels warning-fatal (os.Stderr by default).
+// - outWriter for levels trace-warning (os.Stdout by default);
+// - errWriter for levels error-fatal (os.Stderr by default);
+// the split can be changed with SetErrThreshold() and SetLevelOutput().
 type Logger struct {
-	traceLogger    *log.Logger
-	debugLogger    *log.Logger
-	infoLogger     *log.Logger
-	warningLogger  *log.Logger
-	errorLogger    *log.Logger
-	criticalLogger *log.Logger
-	panicLogger    *log.Logger
-	fatalLogger    *log.Logger
-	flags          int
-	customPrefix   string
-	level          levelType
-	outWriter      io.Writer
-	errWriter      io.Writer
-	calldepth      int
-}
-
-func (l *Logger) updInternalLoggers() {
-	l.traceLogger = log.New(l.outWriter, PrefixTrace+l.customPrefix, l.flags)
-	l.debugLogger = log.New(l.outWriter, PrefixDebug+l.customPrefix, l.flags)
-	l.infoLogger = log.New(l.outWriter, PrefixInfo+l.customPrefix, l.flags)
-	l.warningLogger = log.New(l.outWriter, PrefixWarning+l.customPrefix, l.flags)
-	l.errorLogger = log.New(l.errWriter, PrefixError+l.customPrefix, l.flags)
-	l.criticalLogger = log.New(l.errWriter, PrefixCritical+l.customPrefix, l.flags)
-	l.panicLogger = log.New(l.errWriter, PrefixPanic+l.customPrefix, l.flags)
-	l.fatalLogger = log.New(l.errWriter, PrefixFatal+l.customPrefix, l.flags)
-}
-
-func (l *Logger) updOutputsToLevel() {
-	if l.level == LevelDebug {
-		l.traceLogger.SetOutput(ioutil.Discard)
-	}
-	if l.level == LevelInfo {
-		l.traceLogger.SetOutput(ioutil.Discard)
-		l.debugLogger.SetOutput(ioutil.Discard)
-	}
-	if l.level == LevelWarning {
-		l.traceLogger.SetOutput(ioutil.Discard)
-		l.debugLogger.SetOutput(ioutil.Discard)
-		l.infoLogger.SetOutput(ioutil.Discard)
-	}
-	if l.level == LevelError {
-		l.traceLogger.SetOutput(ioutil.Discard)
-		l.debugLogger.SetOutput(ioutil.Discard)
-		l.infoLogger.SetOutput(ioutil.Discard)
-		l.warningLogger.SetOutput(ioutil.Discard)
-	}
+	core      *core // shared with child loggers
+	calldepth int
+	fields    []Field
 }
 
 // New creates new logger.
 // Use flags==-1 to set default flags
 func New(customPrefix string, flags int) *Logger {
-	if flags < 0 || flags > 255 {
-		flags = FlagsDefault
-	}
 	l := Logger{}
-	l.outWriter = OutDefault
-	l.errWriter = ErrDefault
-	l.calldepth = 3 // as for log.Logger
+	l.core = new(core)
+	l.core.cfg.Store(&config{
+		outWriter:    OutDefault,
+		errWriter:    ErrDefault,
+		errThreshold: LevelError,
+		stackLevel:   LevelOff,
+		exitCode:     1,
+		exitFunc:     os.Exit,
+	})
+	l.calldepth = calldepthDefault
 	l.SetPrefix(customPrefix)
 	l.SetFlags(flags)
 	return &l
@@ -106,23 +70,25 @@ func (l *Logger) setCallDepth(v int) {
 // LevelDebug - to display debug messages and above;
 // LevelInfo - to display info messages and above;
 // LevelWarning - to display warning messages and above;
-// LevelError - to display error messages and above.
+// LevelError - to display error messages and above;
+// LevelCritical - to display critical messages and above;
+// LevelPanic - to display panic and fatal messages;
+// LevelFatal - to display fatal messages only;
+// LevelOff - to suppress all messages
+// (Panic and Fatal still call panic() and os.Exit()).
 // Default level: LevelTrace
-func (l *Logger) SetLevel(level levelType) {
-	l.level = level
-	l.updInternalLoggers()
-	l.updOutputsToLevel()
+func (l *Logger) SetLevel(level Level) {
+	l.update(func(c *config) {
+		c.level = level
+	})
 }
 
 // SetPrefix sets the output prefix for the logger.
 func (l *Logger) SetPrefix(p string) {
 	p = strings.Trim(p, " ")
-	if p != "" {
-		p += " "
-	}
-	l.customPrefix = p
-	l.updInternalLoggers()
-	l.updOutputsToLevel()
+	l.update(func(c *config) {
+		c.customPrefix = p
+	})
 }
 
 // SetFlags sets the output flags for the logger.
@@ -130,247 +96,455 @@ func (l *Logger) SetFlags(f int) {
 	if f < 0 || f > 255 {
 		f = FlagsDefault
 	}
-	l.flags = f
-	l.updInternalLoggers()
-	l.updOutputsToLevel()
+	l.update(func(c *config) {
+		c.flags = f
+	})
 }
 
 // SetOutput sets the output destinations for the logger
 // (different for out and err).
 func (l *Logger) SetOutput(out, err io.Writer) {
-	l.outWriter = out
-	l.errWriter = err
-	l.updInternalLoggers()
-	l.updOutputsToLevel()
+	l.update(func(c *config) {
+		c.outWriter = out
+		c.errWriter = err
+	})
+}
+
+// SetLevelOutput sets the output destinations for messages of the level
+// instead of outWriter/errWriter, e.g. to write traces to a debug file.
+// Several writers get the same messages (tee),
+// no writers resets the level to the out/err split.
+// Note: writers set for the level aren't changed by SetOutput().
+func (l *Logger) SetLevelOutput(level Level, ws ...io.Writer) {
+	if level < LevelTrace || level > LevelFatal {
+		return
+	}
+	var w io.Writer
+	switch len(ws) {
+	case 0:
+	case 1:
+		w = ws[0]
+	default:
+		w = io.MultiWriter(ws...)
+	}
+	l.update(func(c *config) {
+		c.levelWriters[level] = w
+	})
+}
+
+// SetErrThreshold sets the lowest level of messages
+// which are written to errWriter (LevelError by default),
+// messages of lower levels are written to outWriter.
+func (l *Logger) SetErrThreshold(level Level) {
+	l.update(func(c *config) {
+		c.errThreshold = level
+	})
 }
 
-func outputln(calldepth int, ll *log.Logger, v ...interface{}) {
-	ll.Output(calldepth, fmt.Sprintln(v...))
+// SetFormat sets the output format for the logger
+// (FormatText by default).
+func (l *Logger) SetFormat(f Format) {
+	l.update(func(c *config) {
+		c.format = f
+	})
 }
 
-func output(calldepth int, ll *log.Logger, v ...interface{}) {
-	ll.Output(calldepth, fmt.Sprint(v...))
+func (l *Logger) outputln(lvl Level, v ...interface{}) {
+	c := l.config()
+	if lvl < c.level {
+		return
+	}
+	l.emit(c, lvl, fmt.Sprintln(v...), nil)
 }
 
-func outputf(calldepth int, ll *log.Logger, format string, v ...interface{}) {
-	ll.Output(calldepth, fmt.Sprintf(format, v...))
+func (l *Logger) output(lvl Level, v ...interface{}) {
+	c := l.config()
+	if lvl < c.level {
+		return
+	}
+	l.emit(c, lvl, fmt.Sprint(v...), nil)
+}
+
+func (l *Logger) outputf(lvl Level, format string, v ...interface{}) {
+	c := l.config()
+	if lvl < c.level {
+		return
+	}
+	l.emit(c, lvl, fmt.Sprintf(format, v...), nil)
+}
+
+func (l *Logger) outputw(lvl Level, msg string, keyvals []interface{}) {
+	c := l.config()
+	// the level comes from callers, so it can be invalid
+	if lvl < c.level || lvl < LevelTrace || lvl >= LevelOff {
+		return
+	}
+	l.emit(c, lvl, msg, keyvals)
+}
+
+// emit builds an entry and writes it to the output destination of c.
+// It must be called directly from output* helpers
+// to preserve calldepth.
+func (l *Logger) emit(c *config, lvl Level, msg string, keyvals []interface{}) {
+	e := Entry{
+		Level:   lvl,
+		Time:    time.Now(),
+		Prefix:  c.customPrefix,
+		Message: strings.TrimSuffix(msg, "\n"),
+		Fields:  appendFields(l.fields[:len(l.fields):len(l.fields)], keyvals),
+	}
+	if lvl >= c.stackLevel && lvl < LevelOff {
+		e.Stack = callerStack(l.calldepth)
+		if len(e.Stack) > 0 {
+			e.Caller = e.Stack[0]
+		}
+	} else if c.needCaller() {
+		e.Caller = callerFrame(l.calldepth)
+	}
+	l.write(c, &e)
+}
+
+// write samples and deduplicates e and writes it
+// to the output destination of c.
+func (l *Logger) write(c *config, e *Entry) {
+	if c.sampler != nil && !c.sampler.allow(e) {
+		return
+	}
+	if c.dedup != nil && !c.dedup.allow(c, e) {
+		return
+	}
+	l.dispatch(c, e)
+}
+
+// dispatch writes e to the output destination of c
+// directly or via the async queue.
+func (l *Logger) dispatch(c *config, e *Entry) {
+	if q := c.async; q != nil {
+		switch {
+		case e.Level >= LevelPanic:
+			// the process may be stopped right after the call
+			q.flush()
+		case e.Level >= LevelError && q.cfg.SyncErrors:
+		default:
+			q.push(c, e)
+			return
+		}
+	}
+	l.writeEntry(c, e)
+}
+
+// writeEntry fires hooks and writes e to the output destination of c.
+func (l *Logger) writeEntry(c *config, e *Entry) {
+	c.fireHooks(e)
+	if c.slogHandler != nil {
+		forwardToSlog(c.slogHandler, e)
+		return
+	}
+	buf := c.format.encode(nil, e, c.flags)
+	l.core.mu.Lock()
+	defer l.core.mu.Unlock()
+	c.writer(e.Level).Write(buf)
 }
 
 // Trace prints trace message to l.outWriter.
-// Trace calls l.traceLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use trace messages for developing process to trace
 // function calls.
 func (l *Logger) Trace(v ...interface{}) {
-	output(l.calldepth, l.traceLogger, v...)
+	l.output(LevelTrace, v...)
 }
 
 // Traceln prints trace message to l.outWriter.
-// Traceln calls l.traceLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use trace messages for developing process to trace
 // function calls.
 func (l *Logger) Traceln(v ...interface{}) {
-	outputln(l.calldepth, l.traceLogger, v...)
+	l.outputln(LevelTrace, v...)
 }
 
 // Tracef prints trace message to l.outWriter.
-// Tracef calls l.traceLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use trace messages for developing process to trace
 // function calls.
 func (l *Logger) Tracef(format string, v ...interface{}) {
-	outputf(l.calldepth, l.traceLogger, format, v...)
+	l.outputf(LevelTrace, format, v...)
+}
+
+// Tracew prints trace message with key/value pairs to l.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use trace messages for developing process to trace
+// function calls.
+func (l *Logger) Tracew(msg string, keyvals ...interface{}) {
+	l.outputw(LevelTrace, msg, keyvals)
+}
+
+// TraceContext prints trace message to l.outWriter
+// with fields extracted from ctx by the logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) TraceContext(ctx context.Context, v ...interface{}) {
+	l.outputctx(ctx, LevelTrace, v...)
 }
 
 // Debug prints debug message to l.outWriter.
-// Debug calls l.debugLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use debug messages to debug your business logic.
 func (l *Logger) Debug(v ...interface{}) {
-	output(l.calldepth, l.debugLogger, v...)
+	l.output(LevelDebug, v...)
 }
 
 // Debugln prints debug message to l.outWriter.
-// Debugln calls l.debugLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use debug messages to debug your business logic.
 func (l *Logger) Debugln(v ...interface{}) {
-	outputln(l.calldepth, l.debugLogger, v...)
+	l.outputln(LevelDebug, v...)
 }
 
 // Debugf prints debug message to l.outWriter.
-// Debugf calls l.debugLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use debug messages to debug your business logic.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	outputf(l.calldepth, l.debugLogger, format, v...)
+	l.outputf(LevelDebug, format, v...)
+}
+
+// Debugw prints debug message with key/value pairs to l.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use debug messages to debug your business logic.
+func (l *Logger) Debugw(msg string, keyvals ...interface{}) {
+	l.outputw(LevelDebug, msg, keyvals)
+}
+
+// DebugContext prints debug message to l.outWriter
+// with fields extracted from ctx by the logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) DebugContext(ctx context.Context, v ...interface{}) {
+	l.outputctx(ctx, LevelDebug, v...)
 }
 
 // Info prints info message to l.outWriter.
-// Info calls l.infoLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use info messages for common information.
 func (l *Logger) Info(v ...interface{}) {
-	output(l.calldepth, l.infoLogger, v...)
+	l.output(LevelInfo, v...)
 }
 
 // Infoln prints info message to l.outWriter.
-// Infoln calls l.infoLogger to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use info messages for common information.
 func (l *Logger) Infoln(v ...interface{}) {
-	outputln(l.calldepth, l.infoLogger, v...)
+	l.outputln(LevelInfo, v...)
 }
 
 // Infof prints info message to l.outWriter.
-// Infof calls l.infoLogger to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use info messages for common information.
 func (l *Logger) Infof(format string, v ...interface{}) {
-	outputf(l.calldepth, l.infoLogger, format, v...)
+	l.outputf(LevelInfo, format, v...)
+}
+
+// Infow prints info message with key/value pairs to l.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use info messages for common information.
+func (l *Logger) Infow(msg string, keyvals ...interface{}) {
+	l.outputw(LevelInfo, msg, keyvals)
+}
+
+// InfoContext prints info message to l.outWriter
+// with fields extracted from ctx by the logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) InfoContext(ctx context.Context, v ...interface{}) {
+	l.outputctx(ctx, LevelInfo, v...)
 }
 
 // Print is equivalent to l.Info()
 func (l *Logger) Print(v ...interface{}) {
-	output(l.calldepth, l.infoLogger, v...)
+	l.output(LevelInfo, v...)
 }
 
 // Println is equivalent to l.Infoln()
 func (l *Logger) Println(v ...interface{}) {
-	outputln(l.calldepth, l.infoLogger, v...)
+	l.outputln(LevelInfo, v...)
 }
 
 // Printf is equivalent to l.Infof()
 func (l *Logger) Printf(format string, v ...interface{}) {
-	outputf(l.calldepth, l.infoLogger, format, v...)
+	l.outputf(LevelInfo, format, v...)
+}
+
+// Logw prints message with key/value pairs at the level
+// which is known only at runtime (e.g. in middlewares).
+// It doesn't call panic() and os.Exit() for LevelPanic and LevelFatal,
+// messages of invalid levels (e.g. LevelOff) are dropped.
+func (l *Logger) Logw(level Level, msg string, keyvals ...interface{}) {
+	l.outputw(level, msg, keyvals)
 }
 
 // Warning prints warning message to l.outWriter.
-// Warning calls l.warningLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use warning messages for handled errors which don't brake
 // business logic but should be noted (mostly for developers).
 func (l *Logger) Warning(v ...interface{}) {
-	output(l.calldepth, l.warningLogger, v...)
+	l.output(LevelWarning, v...)
 }
 
 // Warningln prints warning message to l.outWriter.
-// Warningln calls l.warningLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use warning messages for handled errors which don't brake
 // business logic but should be noted (mostly for developers).
 func (l *Logger) Warningln(v ...interface{}) {
-	outputln(l.calldepth, l.warningLogger, v...)
+	l.outputln(LevelWarning, v...)
 }
 
 // Warningf prints warning message to l.outWriter.
-// Warningf calls l.warningLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use warning messages for handled errors which don't brake
 // business logic but should be noted (mostly for developers).
 func (l *Logger) Warningf(format string, v ...interface{}) {
-	outputf(l.calldepth, l.warningLogger, format, v...)
+	l.outputf(LevelWarning, format, v...)
+}
+
+// Warningw prints warning message with key/value pairs to l.outWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use warning messages for handled errors which don't brake
+// business logic but should be noted (mostly for developers).
+func (l *Logger) Warningw(msg string, keyvals ...interface{}) {
+	l.outputw(LevelWarning, msg, keyvals)
+}
+
+// WarningContext prints warning message to l.outWriter
+// with fields extracted from ctx by the logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) WarningContext(ctx context.Context, v ...interface{}) {
+	l.outputctx(ctx, LevelWarning, v...)
 }
 
 // Error prints info message to l.errWriter.
-// Error calls l.errorLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use error messages for errors which mostly don't brake
 // business logic.
 func (l *Logger) Error(v ...interface{}) {
-	output(l.calldepth, l.errorLogger, v...)
+	l.output(LevelError, v...)
 }
 
 // Errorln prints info message to l.errWriter.
-// Errorln calls l.errorLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use error messages for errors which mostly don't brake
 // business logic.
 func (l *Logger) Errorln(v ...interface{}) {
-	outputln(l.calldepth, l.errorLogger, v...)
+	l.outputln(LevelError, v...)
 }
 
 // Errorf prints info message to l.errWriter.
-// Errorf calls l.errorLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use error messages for errors which mostly don't brake
 // business logic.
 func (l *Logger) Errorf(format string, v ...interface{}) {
-	outputf(l.calldepth, l.errorLogger, format, v...)
+	l.outputf(LevelError, format, v...)
+}
+
+// Errorw prints error message with key/value pairs to l.errWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use error messages for errors which mostly don't brake
+// business logic.
+func (l *Logger) Errorw(msg string, keyvals ...interface{}) {
+	l.outputw(LevelError, msg, keyvals)
+}
+
+// ErrorContext prints error message to l.errWriter
+// with fields extracted from ctx by the logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) ErrorContext(ctx context.Context, v ...interface{}) {
+	l.outputctx(ctx, LevelError, v...)
 }
 
 // Critical prints critical message to l.errWriter.
-// Critical calls l.criticalLogger.Print to print to the logger.
 // Arguments are handled in the manner of fmt.Print.
 // Tip: use critical messages for errors which may brake
 // business logic.
 func (l *Logger) Critical(v ...interface{}) {
-	output(l.calldepth, l.criticalLogger, v...)
+	l.output(LevelCritical, v...)
 }
 
 // Criticalln prints critical message to l.errWriter.
-// Criticalln calls l.criticalLogger.Println to print to the logger.
 // Arguments are handled in the manner of fmt.Println.
 // Tip: use critical messages for errors which may brake
 // business logic.
 func (l *Logger) Criticalln(v ...interface{}) {
-	outputln(l.calldepth, l.criticalLogger, v...)
+	l.outputln(LevelCritical, v...)
 }
 
 // Criticalf prints critical message to l.errWriter.
-// Criticalf calls l.criticalLogger.Printf to print to the logger.
 // Arguments are handled in the manner of fmt.Printf.
 // Tip: use critical messages for errors which may brake
 // business logic.
 func (l *Logger) Criticalf(format string, v ...interface{}) {
-	outputf(l.calldepth, l.criticalLogger, format, v...)
+	l.outputf(LevelCritical, format, v...)
+}
+
+// Criticalw prints critical message with key/value pairs to l.errWriter.
+// Key/value pairs (key1, value1, key2, value2, ...) are rendered
+// after the message in "key=value" manner.
+// Tip: use critical messages for errors which may brake
+// business logic.
+func (l *Logger) Criticalw(msg string, keyvals ...interface{}) {
+	l.outputw(LevelCritical, msg, keyvals)
+}
+
+// CriticalContext prints critical message to l.errWriter
+// with fields extracted from ctx by the logger's context extractors.
+// Arguments are handled in the manner of fmt.Print.
+func (l *Logger) CriticalContext(ctx context.Context, v ...interface{}) {
+	l.outputctx(ctx, LevelCritical, v...)
 }
 
 // Panic is equivalent to l.Critical() followed by a call to panic().
 func (l *Logger) Panic(v ...interface{}) {
 	s := fmt.Sprint(v...)
-	output(l.calldepth, l.panicLogger, s)
+	l.output(LevelPanic, s)
 	panic(s)
 }
 
 // Panicln is equivalent to l.Criticalln() followed by a call to panic().
 func (l *Logger) Panicln(v ...interface{}) {
 	s := fmt.Sprintln(v...)
-	outputln(l.calldepth, l.panicLogger, s)
+	l.output(LevelPanic, s)
 	panic(s)
 }
 
 // Panicln is equivalent to l.Criticalf() followed by a call to panic().
 func (l *Logger) Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
-	outputf(l.calldepth, l.panicLogger, format, s)
+	l.output(LevelPanic, s)
 	panic(s)
 }
 
-// Fatal prints fatal message to l.errWriter.
-// Fatal calls l.fatalLogger.Print to print to the logger
-// followed by a call to os.Exit(1).
+// Fatal prints fatal message to l.errWriter
+// followed by calls of exit handlers, flush of outputs
+// and a call to the exit function (os.Exit(1) by default).
 // Note: recover() can't intercept Fatal.
 func (l *Logger) Fatal(v ...interface{}) {
-	output(l.calldepth, l.fatalLogger, v...)
-	os.Exit(1)
+	l.output(LevelFatal, v...)
+	l.exit()
 }
 
-// Fatalln prints fatal message to l.errWriter.
-// Fatalln calls l.fatalLogger.Print to print to the logger
-// followed by a call to os.Exit(1).
+// Fatalln prints fatal message to l.errWriter
+// followed by calls of exit handlers, flush of outputs
+// and a call to the exit function (os.Exit(1) by default).
 // Note: recover() can't intercept Fatalln.
 func (l *Logger) Fatalln(v ...interface{}) {
-	outputln(l.calldepth, l.fatalLogger, v...)
-	os.Exit(1)
+	l.outputln(LevelFatal, v...)
+	l.exit()
 }
 
-// Fatalf prints fatal message to l.errWriter.
-// Fatalf calls l.fatalLogger.Print to print to the logger
-// followed by a call to os.Exit(1).
+// Fatalf prints fatal message to l.errWriter
+// followed by calls of exit handlers, flush of outputs
+// and a call to the exit function (os.Exit(1) by default).
 // Note: recover() can't intercept Fatalf.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
-	outputf(l.calldepth, l.fatalLogger, format, v...)
-	os.Exit(1)
+	l.outputf(LevelFatal, format, v...)
+	l.exit()
 }
@@ -0,0 +1,395 @@
+package golog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LokiEncoding defines the body of Loki push requests.
+type LokiEncoding int
+
+// Encodings of Loki push requests:
+// LokiJSON - JSON body (default);
+// LokiProtobuf - snappy-compressed protobuf body.
+const (
+	LokiJSON LokiEncoding = iota
+	LokiProtobuf
+)
+
+// Defaults of LokiConfig.
+const (
+	lokiBatchSizeDefault  = 1 << 20
+	lokiBatchWaitDefault  = time.Second
+	lokiBufferSizeDefault = 8192
+	lokiMaxRetriesDefault = 10
+	lokiTimeoutDefault    = 10 * time.Second
+	lokiMinBackoff        = 500 * time.Millisecond
+	lokiMaxBackoff        = time.Minute
+)
+
+// LokiConfig defines the Loki push API and the batching of entries.
+// At least one source of labels is required.
+type LokiConfig struct {
+	// URL is the push endpoint, e.g. "http://loki:3100/loki/api/v1/push".
+	URL      string
+	Encoding LokiEncoding
+	// TenantID is sent in X-Scope-OrgID header if it's set.
+	TenantID string
+	// Labels are static labels of all entries, e.g. {"job": "billing"}.
+	Labels map[string]string
+	// LevelLabel is the name of the label with the level (e.g. "level"),
+	// the level isn't a label if it's empty.
+	LevelLabel string
+	// PrefixLabel is the name of the label with the custom prefix
+	// (e.g. "app"), the prefix isn't a label if it's empty.
+	PrefixLabel string
+	// Format is the format of log lines (FormatText by default),
+	// lines contain the caller as with log.Lshortfile.
+	Format Format
+	// BatchSize is the max size of lines in one request in bytes (1MB by default).
+	BatchSize int
+	// BatchWait is the max delay of buffered entries (1s by default).
+	BatchWait time.Duration
+	// BufferSize is the max number of buffered entries (8192 by default),
+	// the oldest entries are dropped if the buffer is full.
+	BufferSize int
+	// MaxRetries is the number of retries of requests failed
+	// with 429 or 5xx status or a network error (10 by default),
+	// the batch is dropped after that.
+	MaxRetries int
+	// Client is used for requests, the default client has 10s timeout.
+	Client *http.Client
+}
+
+type lokiEntry struct {
+	labels []Field // sorted by names
+	key    string  // labels as {k="v", ...}
+	time   time.Time
+	line   string
+}
+
+// LokiHook is a hook which pushes entries to Grafana Loki.
+// Entries are buffered and pushed in batches by a background
+// goroutine when BatchSize is reached or BatchWait elapsed.
+// Requests failed with 429 or 5xx statuses or network errors
+// are retried with exponential backoff, entries of requests
+// rejected with other statuses are dropped and reported to ErrDefault.
+type LokiHook struct {
+	cfg    LokiConfig
+	static []Field // static labels
+	batch  batcher // of lokiEntry sized by lines
+
+	// used by the background goroutine only
+	retries int
+}
+
+// NewLokiHook returns the hook which pushes entries of all levels,
+// add it with AddHook(). Close the hook to push buffered entries
+// and stop its goroutine.
+func NewLokiHook(cfg LokiConfig) (*LokiHook, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("golog: Loki URL is not set")
+	}
+	if len(cfg.Labels) == 0 && cfg.LevelLabel == "" && cfg.PrefixLabel == "" {
+		return nil, errors.New("golog: Loki labels are not set")
+	}
+	h := &LokiHook{}
+	for _, name := range []string{cfg.LevelLabel, cfg.PrefixLabel} {
+		if name != "" && !isLokiLabel(name) {
+			return nil, fmt.Errorf("golog: invalid Loki label name %q", name)
+		}
+	}
+	for name, value := range cfg.Labels {
+		if !isLokiLabel(name) {
+			return nil, fmt.Errorf("golog: invalid Loki label name %q", name)
+		}
+		h.static = append(h.static, Field{Key: name, Value: value})
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = lokiBatchSizeDefault
+	}
+	if cfg.BatchWait <= 0 {
+		cfg.BatchWait = lokiBatchWaitDefault
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = lokiBufferSizeDefault
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = lokiMaxRetriesDefault
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: lokiTimeoutDefault}
+	}
+	h.cfg = cfg
+	timeout := cfg.Client.Timeout
+	if timeout <= 0 {
+		timeout = lokiTimeoutDefault
+	}
+	h.batch = batcher{
+		name:      "Loki",
+		bufSize:   cfg.BufferSize,
+		batchSize: cfg.BatchSize,
+		interval:  cfg.BatchWait,
+		timeout:   timeout,
+		send:      h.push,
+	}
+	h.batch.start(lokiMinBackoff, lokiMaxBackoff)
+	return h, nil
+}
+
+// isLokiLabel reports whether name is a valid label name.
+func isLokiLabel(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= '0' && c <= '9' && i > 0) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// Levels implements Hook.
+func (h *LokiHook) Levels() []Level {
+	return AllLevels
+}
+
+// Fire implements Hook, it buffers e.
+func (h *LokiHook) Fire(e *Entry) error {
+	line := h.cfg.Format.encode(nil, e, log.Lshortfile)
+	labels, key := h.labels(e)
+	le := lokiEntry{
+		labels: labels,
+		key:    key,
+		time:   e.Time,
+		line:   string(bytes.TrimSuffix(line, []byte{'\n'})),
+	}
+	return h.batch.add(le, len(le.line))
+}
+
+// labels returns labels of the stream of e sorted by names
+// and as the string {k="v", ...}.
+func (h *LokiHook) labels(e *Entry) ([]Field, string) {
+	labels := append([]Field(nil), h.static...)
+	if h.cfg.LevelLabel != "" {
+		labels = append(labels, Field{Key: h.cfg.LevelLabel, Value: e.Level.String()})
+	}
+	if p := prefixName(e.Prefix); h.cfg.PrefixLabel != "" && p != "" {
+		labels = append(labels, Field{Key: h.cfg.PrefixLabel, Value: p})
+	}
+	sort.SliceStable(labels, func(i, j int) bool { return labels[i].Key < labels[j].Key })
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(l.Key)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(l.Value.(string)))
+	}
+	b.WriteByte('}')
+	return labels, b.String()
+}
+
+// Dropped returns the number of entries dropped because the buffer
+// was full or Loki rejected them.
+func (h *LokiHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.batch.dropped)
+}
+
+// Flush pushes buffered entries without waiting for backoff of retries,
+// it waits no longer than the timeout of the client (10s if it's not set).
+func (h *LokiHook) Flush() error {
+	return h.batch.flush()
+}
+
+// Close pushes buffered entries (one attempt)
+// and stops the background goroutine.
+// Entries fired after Close are not pushed.
+func (h *LokiHook) Close() error {
+	return h.batch.close()
+}
+
+// lokiError is an error of the push request which can be retried.
+type lokiError struct {
+	err error
+}
+
+func (e lokiError) Error() string {
+	return e.err.Error()
+}
+
+// push pushes the batch, it returns the number of pushed or dropped
+// entries: entries of rejected and exhausted requests are dropped.
+func (h *LokiHook) push(batch []batchItem) (int, error) {
+	entries := make([]lokiEntry, len(batch))
+	for i, it := range batch {
+		entries[i] = it.value.(lokiEntry)
+	}
+	err := h.send(entries)
+	if _, ok := err.(lokiError); ok {
+		if h.retries++; h.retries <= h.cfg.MaxRetries {
+			return 0, err
+		}
+		err = fmt.Errorf("%v, %d retries failed", err, h.cfg.MaxRetries)
+	}
+	if err != nil {
+		fmt.Fprintf(ErrDefault, "golog: dropped %d entries pushed to Loki: %v\n", len(entries), err)
+		atomic.AddUint64(&h.batch.dropped, uint64(len(entries)))
+	}
+	h.retries = 0
+	return len(entries), nil
+}
+
+// send sends entries in one push request.
+func (h *LokiHook) send(entries []lokiEntry) error {
+	streams := groupLokiStreams(entries)
+	var body []byte
+	contentType := "application/json"
+	if h.cfg.Encoding == LokiProtobuf {
+		body = snappyEncode(nil, appendLokiProto(nil, streams))
+		contentType = "application/x-protobuf"
+	} else {
+		body = appendLokiJSON(nil, streams)
+	}
+	req, err := http.NewRequest("POST", h.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if h.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", h.cfg.TenantID)
+	}
+	resp, err := h.cfg.Client.Do(req)
+	if err != nil {
+		return lokiError{err}
+	}
+	defer resp.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	err = fmt.Errorf("status %s", resp.Status)
+	if msg = bytes.TrimSpace(msg); len(msg) > 0 {
+		err = fmt.Errorf("status %s: %s", resp.Status, msg)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5 {
+		return lokiError{err}
+	}
+	return err
+}
+
+type lokiStream struct {
+	labels  []Field
+	key     string
+	entries []lokiEntry
+}
+
+// groupLokiStreams groups entries by labels keeping their order.
+func groupLokiStreams(entries []lokiEntry) []lokiStream {
+	var streams []lokiStream
+	index := make(map[string]int)
+	for _, e := range entries {
+		i, ok := index[e.key]
+		if !ok {
+			i = len(streams)
+			index[e.key] = i
+			streams = append(streams, lokiStream{labels: e.labels, key: e.key})
+		}
+		streams[i].entries = append(streams[i].entries, e)
+	}
+	return streams
+}
+
+// appendLokiJSON appends the push request with streams to buf as JSON:
+// {"streams":[{"stream":{"k":"v"},"values":[["<unix ns>","line"]]}]}.
+func appendLokiJSON(buf []byte, streams []lokiStream) []byte {
+	buf = append(buf, `{"streams":[`...)
+	for i, s := range streams {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"stream":{`...)
+		for j, l := range s.labels {
+			if j > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, l.Key)
+			buf = append(buf, ':')
+			buf = appendJSONString(buf, l.Value.(string))
+		}
+		buf = append(buf, `},"values":[`...)
+		for j, e := range s.entries {
+			if j > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, `["`...)
+			buf = strconv.AppendInt(buf, e.time.UnixNano(), 10)
+			buf = append(buf, `",`...)
+			buf = appendJSONString(buf, e.line)
+			buf = append(buf, ']')
+		}
+		buf = append(buf, "]}"...)
+	}
+	return append(buf, "]}"...)
+}
+
+// appendLokiProto appends the push request with streams to buf
+// as logproto.PushRequest message:
+// PushRequest{streams=1: Stream{labels=1, entries=2: Entry{timestamp=1, line=2}}},
+// timestamp is google.protobuf.Timestamp{seconds=1, nanos=2}.
+func appendLokiProto(buf []byte, streams []lokiStream) []byte {
+	var stream, entry, ts []byte
+	for _, s := range streams {
+		stream = appendProtoString(stream[:0], 1, s.key)
+		for _, e := range s.entries {
+			ts = appendProtoVarint(ts[:0], 1, uint64(e.time.Unix()))
+			ts = appendProtoVarint(ts, 2, uint64(e.time.Nanosecond()))
+			entry = appendProtoBytes(entry[:0], 1, ts)
+			entry = appendProtoString(entry, 2, e.line)
+			stream = appendProtoBytes(stream, 2, entry)
+		}
+		buf = appendProtoBytes(buf, 1, stream)
+	}
+	return buf
+}
+
+// appendProtoVarint appends the varint field, zero values are omitted.
+func appendProtoVarint(buf []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = appendUvarint(buf, uint64(field<<3))
+	return appendUvarint(buf, v)
+}
+
+// appendProtoBytes appends the length-delimited field.
+func appendProtoBytes(buf []byte, field int, b []byte) []byte {
+	buf = appendUvarint(buf, uint64(field<<3|2))
+	buf = appendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendProtoString(buf []byte, field int, s string) []byte {
+	buf = appendUvarint(buf, uint64(field<<3|2))
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
@@ -0,0 +1,244 @@
+package golog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lokiServer records bodies of push requests and
+// responds with statuses from the list (204 after its end).
+type lokiServer struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (s *lokiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r)
+	s.bodies = append(s.bodies, body)
+	status := http.StatusNoContent
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestLokiJSON(t *testing.T) {
+	srv := &lokiServer{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	h, err := NewLokiHook(LokiConfig{
+		URL:         ts.URL,
+		TenantID:    "team-a",
+		Labels:      map[string]string{"job": "billing"},
+		LevelLabel:  "level",
+		PrefixLabel: "app",
+		Format:      FormatLogfmt,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := New("api:", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+	l.Info("one")
+	l.Error("two")
+	l.Info("three")
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(srv.bodies) != 1 || srv.requests[0].Header.Get("X-Scope-OrgID") != "team-a" ||
+		srv.requests[0].Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected requests: %v", srv.requests)
+	}
+	var req struct {
+		Streams []struct {
+			Stream map[string]string
+			Values [][2]string
+		}
+	}
+	if err := json.Unmarshal(srv.bodies[0], &req); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range req.Streams {
+		got = append(got, s.Stream["job"]+" "+s.Stream["app"]+" "+s.Stream["level"])
+		for _, v := range s.Values {
+			i := strings.Index(v[1], " msg=")
+			got = append(got, v[1][i+1:])
+		}
+	}
+	want := "billing api info|msg=one|msg=three|billing api error|msg=two"
+	if strings.Join(got, "|") != want {
+		t.Errorf("got %q, want %q", strings.Join(got, "|"), want)
+	}
+}
+
+// snappyDecode decodes the snappy block format.
+func snappyDecode(src []byte) []byte {
+	n, i := binary.Uvarint(src)
+	dst := make([]byte, 0, n)
+	for src = src[i:]; len(src) > 0; {
+		tag := src[0]
+		switch tag & 3 {
+		case 0:
+			size, hdr := int(tag>>2)+1, 1
+			if tag>>2 == 60 {
+				size, hdr = int(src[1])+1, 2
+			} else if tag>>2 == 61 {
+				size, hdr = int(src[1])|int(src[2])<<8+1, 3
+			}
+			dst = append(dst, src[hdr:hdr+size]...)
+			src = src[hdr+size:]
+		case 2:
+			size, offset := int(tag>>2)+1, int(src[1])|int(src[2])<<8
+			for j := 0; j < size; j++ {
+				dst = append(dst, dst[len(dst)-offset])
+			}
+			src = src[3:]
+		default:
+			panic("unsupported tag")
+		}
+	}
+	return dst
+}
+
+// protoFields returns length-delimited fields of the message with the number.
+func protoFields(msg []byte, number uint64) [][]byte {
+	var fields [][]byte
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		msg = msg[n:]
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(msg)
+			msg = msg[n:]
+		case 2:
+			size, n := binary.Uvarint(msg)
+			if key>>3 == number {
+				fields = append(fields, msg[n:n+int(size)])
+			}
+			msg = msg[n+int(size):]
+		}
+	}
+	return fields
+}
+
+func TestLokiProtobuf(t *testing.T) {
+	srv := &lokiServer{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	h, err := NewLokiHook(LokiConfig{URL: ts.URL, Encoding: LokiProtobuf, LevelLabel: "level"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := New("", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+	for i := 0; i < 20; i++ {
+		l.Warning("repeated message to be compressed")
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(srv.bodies) != 1 || srv.requests[0].Header.Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("unexpected requests: %v", srv.requests)
+	}
+	body := snappyDecode(srv.bodies[0])
+	if len(srv.bodies[0]) >= len(body)/2 {
+		t.Errorf("body isn't compressed: %d bytes of %d", len(srv.bodies[0]), len(body))
+	}
+	streams := protoFields(body, 1)
+	if len(streams) != 1 || string(protoFields(streams[0], 1)[0]) != `{level="warning"}` {
+		t.Fatalf("unexpected streams: %q", streams)
+	}
+	entries := protoFields(streams[0], 2)
+	if len(entries) != 20 || len(protoFields(entries[0], 1)) != 1 ||
+		!strings.HasPrefix(string(protoFields(entries[0], 2)[0]), "[WRN] loki_test.go:") {
+		t.Errorf("unexpected entries: %q", entries)
+	}
+}
+
+func TestLokiRetry(t *testing.T) {
+	srv := &lokiServer{statuses: []int{503, 429, 204, 400}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	h, err := NewLokiHook(LokiConfig{URL: ts.URL, Labels: map[string]string{"job": "test"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := New("", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+
+	l.Info("retried")
+	for i, want := range []bool{false, false, true} {
+		if err := h.Flush(); (err == nil) != want {
+			t.Errorf("flush %d: %v", i, err)
+		}
+	}
+	l.Info("rejected")
+	h.Flush()
+	if len(srv.bodies) != 4 || string(srv.bodies[0]) != string(srv.bodies[2]) || h.Dropped() != 1 {
+		t.Errorf("%d requests, %d dropped", len(srv.bodies), h.Dropped())
+	}
+}
+
+// blockingTransport blocks requests until it's closed.
+type blockingTransport chan struct{}
+
+func (t blockingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	<-t
+	return nil, errors.New("unavailable")
+}
+
+func TestLokiFlushTimeout(t *testing.T) {
+	tr := make(blockingTransport)
+	h, err := NewLokiHook(LokiConfig{
+		URL:    "http://loki:3100/loki/api/v1/push",
+		Labels: map[string]string{"job": "test"},
+		Client: &http.Client{Transport: tr, Timeout: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer close(tr)
+	l := New("", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+
+	l.Info("stuck")
+	start := time.Now()
+	if err := h.Flush(); err == nil || time.Since(start) > time.Second {
+		t.Errorf("flush returned %v after %v", err, time.Since(start))
+	}
+}
+
+func TestSnappy(t *testing.T) {
+	var src []byte
+	for i := 0; len(src) < 200000; i++ {
+		src = append(src, strings.Repeat(string(rune('a'+i*7%26)), i%70+1)...)
+		src = append(src, byte(i), byte(i>>8))
+	}
+	if got := snappyDecode(snappyEncode(nil, src)); string(got) != string(src) {
+		t.Errorf("round trip failed: %d bytes of %d", len(got), len(src))
+	}
+}
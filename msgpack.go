@@ -0,0 +1,316 @@
+package golog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// MessagePack encoding of the values used by the Fluentd forward protocol,
+// see https://github.com/msgpack/msgpack/blob/master/spec.md.
+
+// msgpackExt is an extension value, e.g. EventTime (type 0) of Fluentd.
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// appendMsgpackArray appends the header of the array of n elements.
+func appendMsgpackArray(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return append(buf, 0xdc, byte(n>>8), byte(n))
+	}
+	return appendUint32(append(buf, 0xdd), uint32(n))
+}
+
+// appendMsgpackMap appends the header of the map of n pairs.
+func appendMsgpackMap(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return append(buf, 0xde, byte(n>>8), byte(n))
+	}
+	return appendUint32(append(buf, 0xdf), uint32(n))
+}
+
+func appendMsgpackString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xda, byte(n>>8), byte(n))
+	default:
+		buf = appendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func appendMsgpackBinary(buf []byte, b []byte) []byte {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xc5, byte(n>>8), byte(n))
+	default:
+		buf = appendUint32(append(buf, 0xc6), uint32(n))
+	}
+	return append(buf, b...)
+}
+
+func appendMsgpackInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendMsgpackUint(buf, uint64(i))
+	case i >= -32:
+		return append(buf, byte(i))
+	case i >= math.MinInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return append(buf, 0xd1, byte(i>>8), byte(i))
+	case i >= math.MinInt32:
+		return appendUint32(append(buf, 0xd2), uint32(i))
+	}
+	return appendUint64(append(buf, 0xd3), uint64(i))
+}
+
+func appendMsgpackUint(buf []byte, u uint64) []byte {
+	switch {
+	case u < 128:
+		return append(buf, byte(u))
+	case u <= math.MaxUint8:
+		return append(buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return append(buf, 0xcd, byte(u>>8), byte(u))
+	case u <= math.MaxUint32:
+		return appendUint32(append(buf, 0xce), uint32(u))
+	}
+	return appendUint64(append(buf, 0xcf), u)
+}
+
+// appendMsgpackEventTime appends t as EventTime
+// of Fluentd: ext type 0 with seconds and nanoseconds.
+func appendMsgpackEventTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xd7, 0x00)
+	buf = appendUint32(buf, uint32(t.Unix()))
+	return appendUint32(buf, uint32(t.Nanosecond()))
+}
+
+// appendMsgpackValue appends v to buf: basic types, slices and maps
+// of interface{} are encoded as is, errors, fmt.Stringers and time.Time
+// as strings, other types are rendered with fmt.Sprint.
+func appendMsgpackValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if v {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case int:
+		return appendMsgpackInt(buf, int64(v))
+	case int8:
+		return appendMsgpackInt(buf, int64(v))
+	case int16:
+		return appendMsgpackInt(buf, int64(v))
+	case int32:
+		return appendMsgpackInt(buf, int64(v))
+	case int64:
+		return appendMsgpackInt(buf, v)
+	case uint:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint8:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint16:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint32:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint64:
+		return appendMsgpackUint(buf, v)
+	case float32:
+		return appendUint32(append(buf, 0xca), math.Float32bits(v))
+	case float64:
+		return appendUint64(append(buf, 0xcb), math.Float64bits(v))
+	case string:
+		return appendMsgpackString(buf, v)
+	case []byte:
+		return appendMsgpackBinary(buf, v)
+	case []string:
+		buf = appendMsgpackArray(buf, len(v))
+		for _, s := range v {
+			buf = appendMsgpackString(buf, s)
+		}
+		return buf
+	case []interface{}:
+		buf = appendMsgpackArray(buf, len(v))
+		for _, e := range v {
+			buf = appendMsgpackValue(buf, e)
+		}
+		return buf
+	case map[string]interface{}:
+		buf = appendMsgpackMap(buf, len(v))
+		for k, e := range v {
+			buf = appendMsgpackString(buf, k)
+			buf = appendMsgpackValue(buf, e)
+		}
+		return buf
+	case time.Time:
+		return appendMsgpackString(buf, v.Format(time.RFC3339Nano))
+	case error:
+		return appendMsgpackString(buf, errorString(v))
+	case fmt.Stringer:
+		return appendMsgpackString(buf, stringerString(v))
+	}
+	return appendMsgpackString(buf, fmt.Sprint(v))
+}
+
+func appendUint32(buf []byte, u uint32) []byte {
+	return append(buf, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendUint64(buf []byte, u uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(u>>32)), uint32(u))
+}
+
+// readMsgpack decodes a value from r: maps are decoded
+// as map[string]interface{} (only string keys are supported),
+// arrays as []interface{}, integers as int64 or uint64,
+// binaries as []byte and extensions as msgpackExt.
+func readMsgpack(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return readMsgpackMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return readMsgpackArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		b, err := readMsgpackBytes(r, int(c&0x1f))
+		return string(b), err
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackUint(r, 1<<(c-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackBytes(r, int(n))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExt(r, 1<<(c-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := readMsgpackUint(r, 1<<(c-0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackExt(r, int(n))
+	case 0xca:
+		u, err := readMsgpackUint(r, 4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := readMsgpackUint(r, 8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return readMsgpackUint(r, 1<<(c-0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := readMsgpackUint(r, size)
+		// sign extension
+		shift := uint(64 - 8*size)
+		return int64(u<<shift) >> shift, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackUint(r, 1<<(c-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		b, err := readMsgpackBytes(r, int(n))
+		return string(b), err
+	case 0xdc, 0xdd:
+		n, err := readMsgpackUint(r, 2<<(c-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, int(n))
+	case 0xde, 0xdf:
+		n, err := readMsgpackUint(r, 2<<(c-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, int(n))
+	}
+	return nil, fmt.Errorf("golog: invalid msgpack type 0x%x", c)
+}
+
+func readMsgpackUint(r *bufio.Reader, size int) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+func readMsgpackBytes(r *bufio.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+func readMsgpackExt(r *bufio.Reader, n int) (interface{}, error) {
+	t, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	b, err := readMsgpackBytes(r, n)
+	return msgpackExt{Type: int8(t), Data: b}, err
+}
+
+func readMsgpackArray(r *bufio.Reader, n int) (interface{}, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		var err error
+		if a[i], err = readMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func readMsgpackMap(r *bufio.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := readMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("golog: unsupported msgpack map key %v", k)
+		}
+		if m[key], err = readMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,57 @@
+package golog
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMsgpack(t *testing.T) {
+	values := []interface{}{
+		nil, true, false,
+		int64(0), int64(127), int64(-1), int64(-32), int64(-33), int64(math.MinInt8), int64(-200),
+		int64(math.MinInt16 - 1), int64(math.MinInt64),
+		uint64(128), uint64(math.MaxUint16), uint64(math.MaxUint32), uint64(math.MaxUint64),
+		1.5, "", "abc", strings.Repeat("s", 40), strings.Repeat("s", 300), strings.Repeat("s", 70000),
+		[]byte("bin"), make([]byte, 300),
+		[]interface{}{int64(1), "a", []interface{}{}},
+		make([]interface{}, 20),
+		map[string]interface{}{"k": "v", "n": map[string]interface{}{}},
+	}
+	var buf []byte
+	for _, v := range values {
+		buf = appendMsgpackValue(buf, v)
+	}
+	r := bufio.NewReader(bytes.NewReader(buf))
+	for _, want := range values {
+		got, err := readMsgpack(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u, ok := got.(uint64); ok && u <= math.MaxInt64 {
+			// non-negative integers are encoded as unsigned, compare them as int64
+			got = int64(u)
+		}
+		if w, ok := want.(uint64); ok && w <= math.MaxInt64 {
+			want = int64(w)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	}
+}
+
+func TestMsgpackTypedNil(t *testing.T) {
+	var err *nilError
+	buf := appendMsgpackValue(nil, error(err))
+	buf = appendMsgpackValue(buf, (*nilStringer)(nil))
+	r := bufio.NewReader(bytes.NewReader(buf))
+	for i := 0; i < 2; i++ {
+		if v, err := readMsgpack(r); err != nil || v != "<nil>" {
+			t.Errorf("got %v, %v", v, err)
+		}
+	}
+}
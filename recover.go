@@ -0,0 +1,82 @@
+package golog
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// panicStack returns the stack trace of the panicking goroutine
+// starting from the function which panicked.
+// It must be called from the deferred function which recovered.
+func panicStack() []runtime.Frame {
+	var pcs [2 * stackDepth]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	var stack []runtime.Frame
+	panicked := false
+	for {
+		f, more := frames.Next()
+		if panicked && !strings.HasPrefix(f.Function, "runtime.") && len(stack) < stackDepth {
+			stack = append(stack, f)
+		}
+		if f.Function == "runtime.gopanic" {
+			panicked = true
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+// logRecovered writes the panic entry for the recovered value v
+// with the stack of the panic.
+func (l *Logger) logRecovered(v interface{}, stack []runtime.Frame) {
+	c := l.config()
+	if LevelPanic < c.level {
+		return
+	}
+	e := Entry{
+		Level:   LevelPanic,
+		Time:    time.Now(),
+		Prefix:  c.customPrefix,
+		Message: fmt.Sprint("recovered panic: ", v),
+		Fields:  l.fields[:len(l.fields):len(l.fields)],
+		Stack:   stack,
+	}
+	if len(stack) > 0 {
+		e.Caller = stack[0]
+	}
+	l.write(c, &e)
+}
+
+// Recover recovers a panic and logs its value with the full stack
+// as a panic message ([PNC] prefix), then re-panics if repanic is true.
+// It must be deferred directly: defer l.Recover(false).
+func (l *Logger) Recover(repanic bool) {
+	if v := recover(); v != nil {
+		l.logRecovered(v, panicStack())
+		if repanic {
+			panic(v)
+		}
+	}
+}
+
+// RecoverWith recovers a panic, logs its value with the full stack
+// as a panic message and calls fn with the value.
+// It must be deferred directly: defer l.RecoverWith(fn).
+func (l *Logger) RecoverWith(fn func(v interface{})) {
+	if v := recover(); v != nil {
+		l.logRecovered(v, panicStack())
+		fn(v)
+	}
+}
+
+// Go runs fn in a new goroutine which recovers and logs panics of fn.
+func (l *Logger) Go(fn func()) {
+	go func() {
+		defer l.Recover(false)
+		fn()
+	}()
+}
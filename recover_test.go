@@ -0,0 +1,57 @@
+package golog
+
+import (
+	"strings"
+	"testing"
+)
+
+func panicky() {
+	panic("boom")
+}
+
+// chanWriter sends each written entry to the channel.
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestGo(t *testing.T) {
+	out := make(chanWriter, 1)
+	l := New("rec:", 0)
+	l.SetOutput(out, out)
+	l.Go(panicky)
+
+	lines := strings.Split(<-out, "\n")
+	if len(lines) < 3 || lines[0] != "[PNC] rec: recovered panic: boom" ||
+		lines[1] != "\tgithub.com/nordborn/golog.panicky" {
+		t.Errorf("unexpected output: %q", lines)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var out syncBuffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+
+	var got interface{}
+	func() {
+		defer l.RecoverWith(func(v interface{}) { got = v })
+		panicky()
+	}()
+	if got != "boom" {
+		t.Errorf("callback got %v", got)
+	}
+
+	defer func() {
+		if v := recover(); v != "boom" {
+			t.Errorf("re-panicked with %v", v)
+		}
+		if n := strings.Count(out.String(), "[PNC] recovered panic: boom"); n != 2 {
+			t.Errorf("logged %d times: %q", n, out.String())
+		}
+	}()
+	defer l.Recover(true)
+	panicky()
+}
@@ -0,0 +1,309 @@
+package golog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation periods for RotateConfig.Every.
+const (
+	RotateNever  time.Duration = 0
+	RotateHourly               = time.Hour
+	RotateDaily                = 24 * time.Hour
+)
+
+// rotateTimeFormat is used in names of rotated files:
+// "app.log" -> "app-2018-11-26T16-57-49.000.log".
+const rotateTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateConfig defines rotation policies of RotatingFile.
+type RotateConfig struct {
+	// MaxSize is the max size of the current file in bytes,
+	// the file is rotated before it exceeds MaxSize (0 - no limit).
+	MaxSize int64
+	// Every is the rotation period (RotateHourly, RotateDaily etc.),
+	// rotation happens at the boundaries of the period in local time
+	// (RotateNever - no time-based rotation).
+	Every time.Duration
+	// MaxBackups is the number of rotated files to keep (0 - keep all).
+	MaxBackups int
+	// Compress enables gzip compression of rotated files.
+	Compress bool
+}
+
+// RotatingFile is an io.WriteCloser which writes to the file
+// with a stable name and rotates it according to RotateConfig.
+// Rotated files are named after the current file with the rotation time:
+// "app.log" -> "app-2018-11-26T16-57-49.000.log[.gz]".
+// It's safe for concurrent use, so it can be passed to SetOutput()
+// as both outWriter and errWriter.
+type RotatingFile struct {
+	mu       sync.Mutex
+	filename string
+	cfg      RotateConfig
+	file     *os.File // nil if reopening after rotation failed
+	closed   bool
+	size     int64
+	next     time.Time  // time of the next time-based rotation
+	postMu   sync.Mutex // serializes compression and cleanup
+	wg       sync.WaitGroup
+	now      func() time.Time
+}
+
+// NewRotatingFile opens (or creates) the file filename for appending
+// and returns RotatingFile which rotates it according to cfg.
+func NewRotatingFile(filename string, cfg RotateConfig) (*RotatingFile, error) {
+	r := &RotatingFile{filename: filename, cfg: cfg, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the current file and updates size and next rotation time.
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	if r.cfg.Every > 0 {
+		// existing file from the previous period is rotated on first write
+		from := r.now()
+		if r.size > 0 && info.ModTime().Before(from) {
+			from = info.ModTime()
+		}
+		r.next = nextBoundary(from, r.cfg.Every)
+	}
+	return nil
+}
+
+// nextBoundary returns the next boundary of the period d after t
+// in t's location.
+func nextBoundary(t time.Time, d time.Duration) time.Time {
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(d).Add(d).Add(-shift)
+}
+
+// Write writes p to the current file, rotating it first
+// if the size or time limit is reached. If the rotation fails,
+// p is written to the current file anyway, the rotation error
+// is returned and the rotation is retried on the next write.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	var rotateErr error
+	sizeExceeded := r.cfg.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.cfg.MaxSize
+	periodEnded := r.cfg.Every > 0 && !r.now().Before(r.next)
+	if sizeExceeded || periodEnded {
+		if rotateErr = r.rotate(); rotateErr != nil && r.file == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate closes the current file, renames it to a backup name
+// and opens a new current file.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	return r.rotate()
+}
+
+// rotate renames the current file and opens a new one. If renaming
+// fails, the current file is reopened. If opening fails, r.file is
+// left nil and Write retries to open the file.
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		err := r.file.Close()
+		r.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	backup := r.backupName(r.now())
+	if err := os.Rename(r.filename, backup); err != nil {
+		r.open()
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	// compression and cleanup don't block writers
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.postRotate(backup)
+	}()
+	return nil
+}
+
+// backupName returns the unused name of the rotated file for the time t.
+func (r *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.filename)
+	base := strings.TrimSuffix(r.filename, ext)
+	for {
+		name := base + "-" + t.Format(rotateTimeFormat) + ext
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return name
+		}
+		// several rotations within a millisecond
+		t = t.Add(time.Millisecond)
+	}
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// postRotate compresses the backup if needed and removes
+// backups exceeding MaxBackups. Errors are reported to ErrDefault
+// because there is no caller to return them to.
+func (r *RotatingFile) postRotate(backup string) {
+	r.postMu.Lock()
+	defer r.postMu.Unlock()
+	if r.cfg.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(ErrDefault, "golog: can't compress %s: %v\n", backup, err)
+		}
+	}
+	if r.cfg.MaxBackups > 0 {
+		if err := r.removeOldBackups(); err != nil {
+			fmt.Fprintf(ErrDefault, "golog: can't remove old backups of %s: %v\n", r.filename, err)
+		}
+	}
+}
+
+// backups returns names of rotated files sorted from newest to oldest.
+func (r *RotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(r.filename)
+	base := strings.TrimSuffix(filepath.Base(r.filename), ext)
+	dir := filepath.Dir(r.filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, base+"-") {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		ts = strings.TrimPrefix(ts, base+"-")
+		if _, err := time.Parse(rotateTimeFormat, ts); err != nil {
+			continue
+		}
+		names = append(names, filepath.Join(dir, name))
+	}
+	// the time format sorts lexicographically
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+func (r *RotatingFile) removeOldBackups() error {
+	names, err := r.backups()
+	if err != nil || len(names) <= r.cfg.MaxBackups {
+		return err
+	}
+	for _, name := range names[r.cfg.MaxBackups:] {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressFile replaces name with gzip-compressed name.gz.
+func compressFile(name string) (err error) {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			dst.Close()
+			os.Remove(name + ".gz")
+		}
+	}()
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err != nil {
+		return err
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
+
+// Sync commits the current file to stable storage.
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return os.ErrClosed
+	}
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close closes the current file and waits for pending
+// compression and cleanup of rotated files.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	r.closed = true
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+	return err
+}
@@ -0,0 +1,112 @@
+package golog
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	r, err := NewRotatingFile(name, RotateConfig{MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n"} {
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil || string(b) != "line 4\n" {
+		t.Errorf("current file: %q, %v", b, err)
+	}
+	backups, err := r.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backups: %v", backups)
+	}
+	// the newest backup contains the previous line
+	f, err := os.Open(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadAll(zr); err != nil || string(b) != "line 3\n" {
+		t.Errorf("newest backup: %q, %v", b, err)
+	}
+}
+
+func TestRotatingFileTime(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	now := time.Date(2018, 11, 26, 23, 59, 0, 0, time.Local)
+	r := &RotatingFile{filename: name, cfg: RotateConfig{Every: RotateDaily}, now: func() time.Time { return now }}
+	if err := r.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	r.Write([]byte("day 1\n"))
+	now = now.Add(2 * time.Minute)
+	r.Write([]byte("day 2\n"))
+
+	b, _ := ioutil.ReadFile(name)
+	if string(b) != "day 2\n" {
+		t.Errorf("current file: %q", b)
+	}
+	b, _ = ioutil.ReadFile(filepath.Join(dir, "app-2018-11-27T00-01-00.000.log"))
+	if string(b) != "day 1\n" {
+		t.Errorf("backup: %q", b)
+	}
+}
+
+func TestRotatingFileRenameFailed(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	r, err := NewRotatingFile(name, RotateConfig{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if _, err := r.Write([]byte("line 1\n")); err != nil {
+		t.Fatal(err)
+	}
+	// the current file is removed, so it can't be renamed
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("line 2\n")); err == nil {
+		t.Error("rotation error isn't returned")
+	}
+	if _, err := r.Write([]byte("line 3\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil || string(b) != "line 3\n" {
+		t.Errorf("current file: %q, %v", b, err)
+	}
+	backups, err := r.backups()
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("backups: %v, %v", backups, err)
+	}
+	if b, err := ioutil.ReadFile(backups[0]); err != nil || string(b) != "line 2\n" {
+		t.Errorf("backup: %q, %v", b, err)
+	}
+}
@@ -0,0 +1,100 @@
+package golog
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampleBuckets is the number of counters of the sampler,
+// messages with colliding hashes share a counter.
+const sampleBuckets = 4096
+
+// samplingIntervalDefault is used if SamplingConfig.Interval is not set.
+const samplingIntervalDefault = time.Second
+
+// SamplingConfig defines sampling of entries: for each level and message
+// the first First entries in each Interval are written,
+// then every Thereafter-th one.
+// The first written entry after suppressed ones gets
+// the "suppressed" field with their number.
+type SamplingConfig struct {
+	// Interval is the sampling interval (1s if not set).
+	Interval time.Duration
+	// First is the number of entries written in each interval.
+	First int
+	// Thereafter defines that every Thereafter-th entry is written
+	// after the first ones (0 - none of them).
+	Thereafter int
+	// SampleErrors enables sampling of error entries and above
+	// which are never sampled by default.
+	SampleErrors bool
+}
+
+type sampleCounter struct {
+	mu         sync.Mutex
+	resetAt    time.Time
+	n          int
+	suppressed int
+}
+
+type sampler struct {
+	cfg     SamplingConfig
+	total   *uint64 // atomic counter of the logger's core
+	buckets [sampleBuckets]sampleCounter
+}
+
+// allow reports whether e passes the sampler
+// and adds the "suppressed" field to e if needed.
+func (s *sampler) allow(e *Entry) bool {
+	if e.Level >= LevelError && !s.cfg.SampleErrors {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte{byte(e.Level)})
+	h.Write([]byte(e.Message))
+	b := &s.buckets[h.Sum32()%sampleBuckets]
+
+	b.mu.Lock()
+	if !e.Time.Before(b.resetAt) {
+		b.resetAt = e.Time.Add(s.cfg.Interval)
+		b.n = 0
+	}
+	b.n++
+	after := b.n - s.cfg.First
+	if after > 0 && (s.cfg.Thereafter <= 0 || after%s.cfg.Thereafter != 0) {
+		b.suppressed++
+		b.mu.Unlock()
+		atomic.AddUint64(s.total, 1)
+		return false
+	}
+	suppressed := b.suppressed
+	b.suppressed = 0
+	b.mu.Unlock()
+
+	if suppressed > 0 {
+		e.Fields = append(e.Fields, Field{Key: "suppressed", Value: suppressed})
+	}
+	return true
+}
+
+// SetSampling enables sampling of the logger's entries for
+// high-volume call sites, nil disables it.
+func (l *Logger) SetSampling(cfg *SamplingConfig) {
+	var s *sampler
+	if cfg != nil {
+		s = &sampler{cfg: *cfg, total: &l.core.sampledOut}
+		if s.cfg.Interval <= 0 {
+			s.cfg.Interval = samplingIntervalDefault
+		}
+	}
+	l.update(func(c *config) {
+		c.sampler = s
+	})
+}
+
+// SampledOut returns the number of entries suppressed by sampling.
+func (l *Logger) SampledOut() uint64 {
+	return atomic.LoadUint64(&l.core.sampledOut)
+}
@@ -0,0 +1,37 @@
+package golog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSampling(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetSampling(&SamplingConfig{Interval: time.Hour, First: 2, Thereafter: 3})
+
+	for i := 0; i < 8; i++ {
+		l.Infoln("hot")
+		l.Errorln("error")
+	}
+	l.Debugln("other")
+
+	// hot: 2 first, then every 3rd (5th and 8th), errors aren't sampled
+	want := "[INF] hot\n[ERR] error\n" +
+		"[INF] hot\n[ERR] error\n" +
+		"[ERR] error\n" +
+		"[ERR] error\n" +
+		"[INF] hot suppressed=2\n[ERR] error\n" +
+		"[ERR] error\n" +
+		"[ERR] error\n" +
+		"[INF] hot suppressed=2\n[ERR] error\n" +
+		"[DBG] other\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+	if l.SampledOut() != 4 {
+		t.Errorf("SampledOut() = %d, want 4", l.SampledOut())
+	}
+}
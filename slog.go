@@ -0,0 +1,178 @@
+package golog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// golog levels in terms of slog.Level,
+// levels above LevelError continue the slog's step of 4.
+var slogLevels = [...]slog.Level{
+	LevelTrace:    slog.LevelDebug - 4,
+	LevelDebug:    slog.LevelDebug,
+	LevelInfo:     slog.LevelInfo,
+	LevelWarning:  slog.LevelWarn,
+	LevelError:    slog.LevelError,
+	LevelCritical: slog.LevelError + 4,
+	LevelPanic:    slog.LevelError + 8,
+	LevelFatal:    slog.LevelError + 12,
+}
+
+// SlogLevel returns the slog.Level corresponding to lvl:
+// LevelTrace is slog.LevelDebug-4, LevelCritical is slog.LevelError+4 etc.
+func SlogLevel(lvl Level) slog.Level {
+	if lvl < LevelTrace {
+		lvl = LevelTrace
+	}
+	if int(lvl) >= len(slogLevels) {
+		return slogLevels[LevelFatal] + 4
+	}
+	return slogLevels[lvl]
+}
+
+// LevelFromSlog returns the golog level corresponding to the slog level.
+// Levels between golog's ones are rounded down;
+// it never returns LevelPanic and LevelFatal to avoid
+// panics and exits caused by slog records.
+func LevelFromSlog(lvl slog.Level) Level {
+	for i := LevelCritical; i > LevelTrace; i-- {
+		if lvl >= slogLevels[i] {
+			return i
+		}
+	}
+	return LevelTrace
+}
+
+// slogHandler is a slog.Handler backed by a golog Logger.
+type slogHandler struct {
+	l      *Logger
+	fields []Field
+	group  string // prefix of keys, e.g. "request."
+}
+
+// NewSlogHandler returns a slog.Handler which writes records
+// with the logger l: using its level, prefix, flags, format, outputs
+// and context extractors. Record levels are mapped with LevelFromSlog(),
+// attributes are rendered as fields (keys of groups are joined with '.').
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{l: l}
+}
+
+// Enabled implements slog.Handler.
+func (h *slogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return LevelFromSlog(lvl) >= h.l.config().level
+}
+
+// Handle implements slog.Handler.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	c := h.l.config()
+	lvl := LevelFromSlog(r.Level)
+	if lvl < c.level {
+		return nil
+	}
+	e := Entry{
+		Level:   lvl,
+		Time:    r.Time,
+		Prefix:  c.customPrefix,
+		Message: r.Message,
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if r.PC != 0 {
+		e.Caller, _ = runtime.CallersFrames([]uintptr{r.PC}).Next()
+	}
+	if lvl >= c.stackLevel && lvl < LevelOff {
+		e.Stack = foreignStack("log/slog.")
+	}
+	e.Fields = append(e.Fields, h.l.fields...)
+	e.Fields = append(e.Fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		e.Fields = appendSlogAttr(e.Fields, h.group, a)
+		return true
+	})
+	if ctx != nil {
+		for _, fn := range c.extractors {
+			e.Fields = appendFields(e.Fields, fn(ctx))
+		}
+	}
+	h.l.write(c, &e)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.fields = h.fields[:len(h.fields):len(h.fields)]
+	for _, a := range attrs {
+		h2.fields = appendSlogAttr(h2.fields, h.group, a)
+	}
+	return &h2
+}
+
+// WithGroup implements slog.Handler.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
+
+// appendSlogAttr appends a as a field to dst, groups are flattened.
+func appendSlogAttr(dst []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			dst = appendSlogAttr(dst, group, ga)
+		}
+		return dst
+	}
+	if a.Key == "" {
+		return dst
+	}
+	return append(dst, Field{Key: group + a.Key, Value: a.Value.Any()})
+}
+
+// NewFromSlog creates new logger which forwards its entries
+// to the slog handler h instead of writing them to outputs.
+// Caller information (respecting the logger's calldepth) is passed
+// in the record's PC; the custom prefix, if set with SetPrefix(),
+// is passed as the "prefix" attribute.
+// Flags and format of the logger aren't used.
+func NewFromSlog(h slog.Handler) *Logger {
+	l := New("", -1)
+	l.update(func(c *config) {
+		c.slogHandler = h
+	})
+	return l
+}
+
+// forwardToSlog passes e to h as a slog record.
+func forwardToSlog(h slog.Handler, e *Entry) {
+	lvl := SlogLevel(e.Level)
+	ctx := context.Background()
+	if !h.Enabled(ctx, lvl) {
+		return
+	}
+	var pc uintptr
+	if e.Caller.PC != 0 {
+		// Frame.PC points to the call instruction,
+		// slog expects the return address as from runtime.Callers
+		pc = e.Caller.PC + 1
+	}
+	r := slog.NewRecord(e.Time, lvl, e.Message, pc)
+	if p := prefixName(e.Prefix); p != "" {
+		r.AddAttrs(slog.String("prefix", p))
+	}
+	for _, f := range e.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	h.Handle(ctx, r)
+}
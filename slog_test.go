@@ -0,0 +1,77 @@
+package golog
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var out bytes.Buffer
+	l := New("slog:", log.Lshortfile)
+	l.SetOutput(&out, &out)
+	l.SetLevel(LevelDebug)
+	sl := slog.New(NewSlogHandler(l)).With("service", "api").WithGroup("req")
+
+	_, _, line, _ := runtime.Caller(0)
+	sl.Info("Served", "status", 200, slog.Group("user", "id", 7))
+	sl.Log(context.Background(), SlogLevel(LevelTrace), "hidden")
+	sl.Log(context.Background(), slog.LevelError+4, "Critical")
+
+	want := "[INF] slog: slog_test.go:" + strconv.Itoa(line+1) + ": Served service=api req.status=200 req.user.id=7\n" +
+		"[CRT] slog: slog_test.go:" + strconv.Itoa(line+3) + ": Critical service=api\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestNewFromSlog(t *testing.T) {
+	var out bytes.Buffer
+	h := slog.NewTextHandler(&out, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     SlogLevel(LevelTrace),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	l := NewFromSlog(h)
+	l.SetPrefix("fwd:")
+	_, file, line, _ := runtime.Caller(0)
+	l.With("k", "v").Tracew("Traced", "n", 1)
+
+	want := "level=DEBUG-4 source=" + file + ":" + strconv.Itoa(line+1) + " msg=Traced prefix=fwd k=v n=1\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	for lvl := LevelTrace; lvl <= LevelFatal; lvl++ {
+		if got := LevelFromSlog(SlogLevel(lvl)); got != lvl && lvl < LevelPanic {
+			t.Errorf("LevelFromSlog(SlogLevel(%v)) = %v", lvl, got)
+		}
+	}
+	if !strings.Contains(SlogLevel(LevelCritical).String(), "ERROR+4") {
+		t.Errorf("SlogLevel(LevelCritical) = %v", SlogLevel(LevelCritical))
+	}
+}
+
+func TestSlogHandlerStack(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetStackLevel(LevelError)
+	slog.New(NewSlogHandler(l)).Error("Failed")
+
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 3 || lines[0] != "[ERR] Failed" ||
+		lines[1] != "\tgithub.com/nordborn/golog.TestSlogHandlerStack" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
@@ -0,0 +1,78 @@
+package golog
+
+import "encoding/binary"
+
+// Snappy block format encoder used by LokiHook,
+// see https://github.com/google/snappy/blob/main/format_description.txt.
+
+const (
+	snappyBlockSize = 1 << 16
+	snappyTableBits = 14
+)
+
+// snappyEncode appends src compressed in the snappy block format to dst.
+func snappyEncode(dst, src []byte) []byte {
+	dst = appendUvarint(dst, uint64(len(src)))
+	for len(src) > 0 {
+		block := src
+		if len(block) > snappyBlockSize {
+			block = block[:snappyBlockSize]
+		}
+		dst = snappyEncodeBlock(dst, block)
+		src = src[len(block):]
+	}
+	return dst
+}
+
+// snappyEncodeBlock appends src of at most snappyBlockSize bytes
+// as literals and copies of previous 4-byte matches.
+func snappyEncodeBlock(dst, src []byte) []byte {
+	var table [1 << snappyTableBits]int32 // positions of hashes + 1
+	lit := 0
+	for i := 0; i+4 <= len(src); {
+		x := binary.LittleEndian.Uint32(src[i:])
+		h := (x * 0x1e35a7bd) >> (32 - snappyTableBits)
+		cand := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if cand < 0 || binary.LittleEndian.Uint32(src[cand:]) != x {
+			i++
+			continue
+		}
+		dst = snappyLiteral(dst, src[lit:i])
+		j := i + 4
+		for k := cand + 4; j < len(src) && src[j] == src[k]; j, k = j+1, k+1 {
+		}
+		dst = snappyCopy(dst, i-cand, j-i)
+		i, lit = j, j
+	}
+	return snappyLiteral(dst, src[lit:])
+}
+
+// snappyLiteral appends lit of at most snappyBlockSize bytes.
+func snappyLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	switch n := len(lit) - 1; {
+	case n < 60:
+		dst = append(dst, byte(n<<2))
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	default:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	}
+	return append(dst, lit...)
+}
+
+// snappyCopy appends copies with 2-byte offset of at most 64 bytes each.
+func snappyCopy(dst []byte, offset, length int) []byte {
+	for length > 0 {
+		n := length
+		if n > 64 {
+			n = 64
+		}
+		dst = append(dst, byte((n-1)<<2|2), byte(offset), byte(offset>>8))
+		length -= n
+	}
+	return dst
+}
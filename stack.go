@@ -0,0 +1,92 @@
+package golog
+
+import (
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// stackDepth is the max number of frames in stack traces of entries.
+const stackDepth = 32
+
+// callerStack returns the stack trace starting from the caller
+// without frames of the runtime package,
+// skip is counted as in runtime.Callers from the callerStack's caller.
+func callerStack(skip int) []runtime.Frame {
+	var pcs [stackDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	stack := make([]runtime.Frame, 0, n)
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			stack = append(stack, f)
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+// foreignStack returns the stack trace of the caller's caller
+// without leading frames of functions with the prefix,
+// e.g. "log/slog." for entries coming from other loggers.
+func foreignStack(prefix string) []runtime.Frame {
+	stack := callerStack(2)
+	for len(stack) > 0 && strings.HasPrefix(stack[0].Function, prefix) {
+		stack = stack[1:]
+	}
+	return stack
+}
+
+// SetStackLevel makes entries of the level and above include
+// the stack trace of the calling goroutine (LevelOff by default),
+// e.g. SetStackLevel(LevelCritical).
+// In text format, the stack is rendered as indented lines after the message,
+// in JSON - as an array of "function file:line" strings.
+func (l *Logger) SetStackLevel(level Level) {
+	l.update(func(c *config) {
+		c.stackLevel = level
+	})
+}
+
+// appendStackText appends the stack in the manner of runtime/debug.Stack():
+// each frame as the function and the indented "file:line" lines.
+func appendStackText(buf []byte, stack []runtime.Frame) []byte {
+	for _, f := range stack {
+		buf = append(buf, "\n\t"...)
+		buf = append(buf, f.Function...)
+		buf = append(buf, "\n\t\t"...)
+		buf = appendFrame(buf, f, log.Llongfile)
+	}
+	return buf
+}
+
+// appendFrame appends "file:line" of the frame f,
+// file is short or long according to flags.
+func appendFrame(buf []byte, f runtime.Frame, flags int) []byte {
+	file := f.File
+	if file == "" {
+		file = "???"
+	} else if flags&log.Lshortfile != 0 {
+		for i := len(file) - 1; i > 0; i-- {
+			if file[i] == '/' {
+				file = file[i+1:]
+				break
+			}
+		}
+	}
+	buf = append(buf, file...)
+	buf = append(buf, ':')
+	return strconv.AppendInt(buf, int64(f.Line), 10)
+}
+
+// stackStrings returns frames of the stack as "function file:line" strings.
+func stackStrings(stack []runtime.Frame) []string {
+	s := make([]string, len(stack))
+	for i, f := range stack {
+		s[i] = f.Function + " " + string(appendFrame(nil, f, log.Llongfile))
+	}
+	return s
+}
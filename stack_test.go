@@ -0,0 +1,45 @@
+package golog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func logCritical(l *Logger) {
+	l.Criticalln("crashed")
+}
+
+func TestStackLevel(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetStackLevel(LevelCritical)
+	l.Errorln("no stack")
+	logCritical(l)
+
+	lines := strings.Split(out.String(), "\n")
+	if lines[0] != "[ERR] no stack" || lines[1] != "[CRT] crashed" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+	if lines[2] != "\tgithub.com/nordborn/golog.logCritical" ||
+		!strings.HasPrefix(lines[3], "\t\t") || !strings.HasSuffix(lines[3], "stack_test.go:11") ||
+		lines[4] != "\tgithub.com/nordborn/golog.TestStackLevel" {
+		t.Errorf("unexpected stack: %q", lines[2:])
+	}
+
+	out.Reset()
+	l.SetFormat(FormatJSON)
+	logCritical(l)
+	var e struct {
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Stack) < 2 || !strings.HasPrefix(e.Stack[0], "github.com/nordborn/golog.logCritical /") ||
+		strings.Contains(out.String(), "(*Logger)") {
+		t.Errorf("unexpected stack: %q", e.Stack)
+	}
+}
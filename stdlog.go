@@ -0,0 +1,84 @@
+package golog
+
+import (
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stdFlags are set for std loggers writing to stdWriter,
+// stdWriter extracts the caller from the line and drops the rest.
+const stdFlags = log.Llongfile
+
+// stdWriter is an io.Writer for log.Logger which re-emits
+// each line with the golog logger at the level.
+type stdWriter struct {
+	l     *Logger
+	level Level
+}
+
+// Write implements io.Writer.
+func (w *stdWriter) Write(p []byte) (int, error) {
+	c := w.l.config()
+	if w.level < c.level {
+		return len(p), nil
+	}
+	e := Entry{
+		Level:  w.level,
+		Time:   time.Now(),
+		Prefix: c.customPrefix,
+		Fields: w.l.fields[:len(w.l.fields):len(w.l.fields)],
+	}
+	e.Caller, e.Message = splitStdCaller(strings.TrimSuffix(string(p), "\n"))
+	if w.level >= c.stackLevel && w.level < LevelOff {
+		e.Stack = foreignStack("log.")
+	}
+	w.l.write(c, &e)
+	return len(p), nil
+}
+
+// splitStdCaller splits the line "/path/file.go:23: msg" written
+// with stdFlags to the caller and the message.
+// If there is no caller, the line is returned as the message.
+func splitStdCaller(s string) (runtime.Frame, string) {
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		return runtime.Frame{}, s
+	}
+	j := strings.LastIndexByte(s[:i], ':')
+	if j < 0 {
+		return runtime.Frame{}, s
+	}
+	line, err := strconv.Atoi(s[j+1 : i])
+	if err != nil {
+		return runtime.Frame{}, s
+	}
+	return runtime.Frame{File: s[:j], Line: line}, s[i+2:]
+}
+
+// StdLogger returns a standard library logger which writes
+// its messages with l at the level, e.g. for http.Server.ErrorLog.
+// The std logger's prefix and flags mustn't be changed.
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(&stdWriter{l: l, level: level}, "", stdFlags)
+}
+
+// RedirectStdLog redirects the output of the standard library
+// "log" package to the global logger at the level:
+// the std prefix and flags are stripped, messages get
+// the global logger's prefix and level filtering.
+// It returns the function which restores the previous output,
+// prefix and flags of the "log" package.
+func RedirectStdLog(level Level) (undo func()) {
+	w, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+	log.SetOutput(&stdWriter{l: loggerGlobal, level: level})
+	log.SetFlags(stdFlags)
+	log.SetPrefix("")
+	return func() {
+		log.SetOutput(w)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+	}
+}
@@ -0,0 +1,64 @@
+package golog
+
+import (
+	"bytes"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStdLogger(t *testing.T) {
+	var out bytes.Buffer
+	l := New("std:", log.Lshortfile)
+	l.SetOutput(&out, &out)
+	l.SetLevel(LevelInfo)
+
+	_, _, line, _ := runtime.Caller(0)
+	l.StdLogger(LevelError).Println("Server error")
+	l.StdLogger(LevelDebug).Println("hidden")
+
+	want := "[ERR] std: stdlog_test.go:" + strconv.Itoa(line+1) + ": Server error\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	var out bytes.Buffer
+	SetOutput(&out, &out)
+	defer SetOutput(OutDefault, ErrDefault)
+	SetFlags(0)
+	defer SetFlags(FlagsDefault)
+	SetPrefix("redirect:")
+	defer SetPrefix(customPrefixDefault)
+	SetLevel(LevelTrace)
+
+	log.SetPrefix("lib: ")
+	undo := RedirectStdLog(LevelWarning)
+	log.Printf("deprecated %s", "option")
+	undo()
+
+	if want := "[WRN] redirect: deprecated option\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+	if log.Prefix() != "lib: " || log.Flags() != log.LstdFlags {
+		t.Errorf("std log isn't restored: %q, %d", log.Prefix(), log.Flags())
+	}
+	log.SetPrefix("")
+}
+
+func TestStdLoggerStack(t *testing.T) {
+	var out bytes.Buffer
+	l := New("", 0)
+	l.SetOutput(&out, &out)
+	l.SetStackLevel(LevelError)
+	l.StdLogger(LevelError).Println("Failed")
+
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 3 || lines[0] != "[ERR] Failed" ||
+		lines[1] != "\tgithub.com/nordborn/golog.TestStdLoggerStack" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
@@ -0,0 +1,263 @@
+package golog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SyslogFormat defines the layout of syslog messages.
+type SyslogFormat int
+
+// Formats of syslog messages:
+// SyslogRFC5424 - "<11>1 2018-11-26T16:57:49.000000+03:00 host main 1234 - - Failed" (default);
+// SyslogRFC3164 - "<11>Nov 26 16:57:49 host main[1234]: Failed",
+// the hostname is omitted for the local syslog as by log/syslog.
+const (
+	SyslogRFC5424 SyslogFormat = iota
+	SyslogRFC3164
+)
+
+// syslogTimeout limits dialing and writing to the syslog server,
+// so a stuck server doesn't block logging forever.
+const syslogTimeout = 5 * time.Second
+
+// syslogLocalPaths are the usual paths of the local syslog socket.
+var syslogLocalPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogConfig defines the syslog server and the messages' layout.
+type SyslogConfig struct {
+	// Network is "udp", "tcp", "unixgram" or "unix",
+	// "unix" tries datagram and stream sockets at Addr.
+	// Empty Network and Addr mean the local syslog (/dev/log).
+	Network string
+	// Addr is "host:port" or the socket path.
+	Addr   string
+	Format SyslogFormat
+	// Facility is the syslog facility code (RFC 5424 section 6.2.1),
+	// 0 means 1 (user-level messages).
+	Facility int
+	// Hostname is os.Hostname() by default.
+	Hostname string
+	// AppName is used for entries without the custom prefix,
+	// it's the program's name by default.
+	AppName string
+}
+
+// SyslogHook is a hook which sends entries to syslog.
+// The custom prefix of the logger is used as APP-NAME (tag),
+// levels are mapped to severities: Trace and Debug - debug,
+// Info - info, Warning - warning, Error - err, Critical - crit,
+// Panic - alert, Fatal - emerg.
+// Stream connections (tcp, unix) use octet-counting framing (RFC 6587).
+// The hook reconnects after errors of the connection,
+// entries written just before the error is detected can be lost.
+// After a failed reconnection entries are dropped until the next
+// attempt, attempts are delayed exponentially up to 30s.
+type SyslogHook struct {
+	cfg     SyslogConfig
+	pid     string
+	dropped uint64 // atomic
+	mu      sync.Mutex
+	conn    net.Conn
+	stream  bool
+	local   bool
+	closed  bool
+	retry   backoff
+}
+
+// NewSyslogHook connects to the syslog server and returns the hook
+// which sends entries of all levels, add it with AddHook().
+// Use SetOutput(ioutil.Discard, ioutil.Discard) to log to syslog only.
+func NewSyslogHook(cfg SyslogConfig) (*SyslogHook, error) {
+	if cfg.Facility == 0 {
+		cfg.Facility = 1
+	}
+	if cfg.Facility < 0 || cfg.Facility > 23 {
+		return nil, fmt.Errorf("golog: invalid syslog facility %d", cfg.Facility)
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = filepath.Base(os.Args[0])
+	}
+	h := &SyslogHook{
+		cfg:   cfg,
+		pid:   strconv.Itoa(os.Getpid()),
+		local: cfg.Network == "" && cfg.Addr == "",
+		retry: backoff{min: reconnectMinBackoff, max: reconnectMaxBackoff},
+	}
+	if err := h.connect(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// connect dials the syslog server, h.mu must be held.
+func (h *SyslogHook) connect() error {
+	var networks, addrs []string
+	switch {
+	case h.local:
+		networks, addrs = []string{"unixgram", "unix"}, syslogLocalPaths
+	case h.cfg.Network == "unix":
+		networks, addrs = []string{"unixgram", "unix"}, []string{h.cfg.Addr}
+	default:
+		networks, addrs = []string{h.cfg.Network}, []string{h.cfg.Addr}
+	}
+	var err error
+	for _, addr := range addrs {
+		for _, network := range networks {
+			var conn net.Conn
+			if conn, err = net.DialTimeout(network, addr, syslogTimeout); err == nil {
+				h.conn = conn
+				h.stream = network == "tcp" || network == "tcp4" || network == "tcp6" || network == "unix"
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("golog: failed to connect to syslog: %v", err)
+}
+
+// Levels implements Hook.
+func (h *SyslogHook) Levels() []Level {
+	return AllLevels
+}
+
+// Fire implements Hook.
+func (h *SyslogHook) Fire(e *Entry) error {
+	msg := h.format(nil, e)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	if h.conn != nil {
+		if h.write(msg) == nil {
+			return nil
+		}
+		h.conn.Close()
+		h.conn = nil
+	}
+	if h.retry.wait() {
+		atomic.AddUint64(&h.dropped, 1)
+		return nil
+	}
+	if err := h.connect(); err != nil {
+		h.retry.failed()
+		atomic.AddUint64(&h.dropped, 1)
+		return err
+	}
+	h.retry.reset()
+	return h.write(msg)
+}
+
+// Dropped returns the number of entries dropped
+// because the syslog server was unavailable.
+func (h *SyslogHook) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// write writes msg to the connection, h.mu must be held.
+func (h *SyslogHook) write(msg []byte) error {
+	if h.stream {
+		framed := strconv.AppendInt(make([]byte, 0, len(msg)+8), int64(len(msg)), 10)
+		msg = append(append(framed, ' '), msg...)
+	}
+	h.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+	_, err := h.conn.Write(msg)
+	return err
+}
+
+// Close closes the connection, entries fired after Close are not sent.
+func (h *SyslogHook) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return os.ErrClosed
+	}
+	h.closed = true
+	if h.conn == nil {
+		return nil
+	}
+	return h.conn.Close()
+}
+
+// format appends e to buf as a syslog message.
+func (h *SyslogHook) format(buf []byte, e *Entry) []byte {
+	app := prefixName(e.Prefix)
+	if app == "" {
+		app = h.cfg.AppName
+	}
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(h.cfg.Facility*8+syslogSeverity(e.Level)), 10)
+	buf = append(buf, '>')
+	if h.cfg.Format == SyslogRFC3164 {
+		buf = e.Time.AppendFormat(buf, time.Stamp)
+		buf = append(buf, ' ')
+		if !h.local {
+			buf = appendSyslogName(buf, h.cfg.Hostname, 255)
+			buf = append(buf, ' ')
+		}
+		buf = appendSyslogName(buf, app, 32)
+		buf = append(buf, '[')
+		buf = append(buf, h.pid...)
+		buf = append(buf, "]: "...)
+	} else {
+		buf = append(buf, "1 "...)
+		buf = e.Time.AppendFormat(buf, "2006-01-02T15:04:05.000000Z07:00")
+		buf = append(buf, ' ')
+		buf = appendSyslogName(buf, h.cfg.Hostname, 255)
+		buf = append(buf, ' ')
+		buf = appendSyslogName(buf, app, 48)
+		buf = append(buf, ' ')
+		buf = append(buf, h.pid...)
+		// no MSGID and STRUCTURED-DATA
+		buf = append(buf, " - - "...)
+	}
+	return appendMessageText(buf, e)
+}
+
+// appendSyslogName appends s to buf as a header field of max length n:
+// non-printable and non-ASCII characters are replaced with '_',
+// empty s is replaced with "-" (the NILVALUE).
+func appendSyslogName(buf []byte, s string, n int) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	if len(s) > n {
+		s = s[:n]
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// syslogSeverity returns the syslog severity code of the level.
+func syslogSeverity(lvl Level) int {
+	switch {
+	case lvl <= LevelDebug:
+		return 7
+	case lvl == LevelInfo:
+		return 6
+	case lvl == LevelWarning:
+		return 4
+	case lvl == LevelError:
+		return 3
+	case lvl == LevelCritical:
+		return 2
+	case lvl == LevelPanic:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,187 @@
+package golog
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	h, err := NewSyslogHook(SyslogConfig{Network: "udp", Addr: pc.LocalAddr().String(), Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := New("app:", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+	l.Errorw("failed", "user", "bob smith")
+
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := `^<11>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}\S+ host app ` + strconv.Itoa(os.Getpid()) +
+		` - - failed user="bob smith"$`
+	if got := string(buf[:n]); !regexp.MustCompile(re).MatchString(got) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	h, err := NewSyslogHook(SyslogConfig{
+		Network:  "tcp",
+		Addr:     ln.Addr().String(),
+		Format:   SyslogRFC3164,
+		Facility: 16,
+		Hostname: "host",
+		AppName:  "tool",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	l := New("", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+	l.SetExitFunc(func(int) {})
+	l.Fatal("bye")
+	l.Debug("x")
+
+	r := bufio.NewReader(conn)
+	re := regexp.MustCompile(`^<(\d+)>\w{3} [ \d]\d \d\d:\d\d:\d\d host tool\[\d+\]: (.*)$`)
+	for _, want := range []string{"128 bye", "135 x"} {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, _ := strconv.Atoi(size[:len(size)-1])
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatal(err)
+		}
+		m := re.FindStringSubmatch(string(msg))
+		if m == nil || m[1]+" "+m[2] != want {
+			t.Errorf("got %q, want %q", msg, want)
+		}
+	}
+}
+
+func TestSyslogReconnect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.sock")
+	listen := func() net.PacketConn {
+		pc, err := net.ListenPacket("unixgram", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pc
+	}
+	read := func(pc net.PacketConn) string {
+		buf := make([]byte, 1024)
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:n])
+	}
+
+	pc := listen()
+	h, err := NewSyslogHook(SyslogConfig{Network: "unix", Addr: path, Hostname: "host"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	l := New("app:", 0)
+	l.SetOutput(ioutil.Discard, ioutil.Discard)
+	l.AddHook(h)
+	re := regexp.MustCompile(`^<14>1 \S+ host app \d+ - - (.*)$`)
+
+	l.Info("first")
+	if m := re.FindStringSubmatch(read(pc)); m == nil || m[1] != "first" {
+		t.Errorf("got %q", m)
+	}
+	// the collector is restarted
+	pc.Close()
+	os.Remove(path)
+	pc = listen()
+	defer pc.Close()
+	l.Info("second")
+	if m := re.FindStringSubmatch(read(pc)); m == nil || m[1] != "second" {
+		t.Errorf("got %q", m)
+	}
+}
+
+func TestSyslogReconnectBackoff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log.sock")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewSyslogHook(SyslogConfig{Network: "unixgram", Addr: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	// the collector is stopped
+	pc.Close()
+	os.Remove(path)
+	if err := h.Fire(&Entry{Message: "failed"}); err == nil {
+		t.Error("failed reconnection isn't reported")
+	}
+	pc, err = net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	// the next attempt is delayed
+	if err := h.Fire(&Entry{Message: "dropped"}); err != nil || h.Dropped() != 2 {
+		t.Errorf("%v, %d dropped", err, h.Dropped())
+	}
+	h.mu.Lock()
+	h.retry.retryAt = time.Now()
+	h.mu.Unlock()
+	if err := h.Fire(&Entry{Message: "sent"}); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1024)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil || !strings.HasSuffix(string(buf[:n]), " sent") {
+		t.Errorf("got %q, %v", buf[:n], err)
+	}
+}